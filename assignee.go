@@ -0,0 +1,161 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Assignee はタスクの担当者です。
+// Share はウェイト配分の比率で、0 の場合は 1 として扱います（均等割り）。
+type Assignee struct {
+	Name  string  `json:"name"`
+	Share float64 `json:"share,omitempty"`
+}
+
+// AssigneeList はタスクの担当者一覧です。
+type AssigneeList []Assignee
+
+// UnmarshalJSON は旧形式（"assignees": "hanako" の文字列）も読み込めるようにします。
+func (l *AssigneeList) UnmarshalJSON(data []byte) error {
+	var legacy string
+	if err := json.Unmarshal(data, &legacy); err == nil {
+		legacy = strings.TrimSpace(legacy)
+		if legacy == "" {
+			*l = AssigneeList{}
+		} else {
+			*l = AssigneeList{{Name: legacy}}
+		}
+		return nil
+	}
+
+	var list []Assignee
+	if err := json.Unmarshal(data, &list); err != nil {
+		return err
+	}
+	*l = list
+	return nil
+}
+
+// String は表示用に担当者を "hanako, taro(2)" の形式で連結します。
+func (l AssigneeList) String() string {
+	parts := make([]string, 0, len(l))
+	for _, a := range l {
+		if a.Share > 0 && a.Share != 1 {
+			parts = append(parts, fmt.Sprintf("%s(%g)", a.Name, a.Share))
+		} else {
+			parts = append(parts, a.Name)
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+func (l AssigneeList) Has(name string) bool {
+	for _, a := range l {
+		if a.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// names は担当者名の一覧を返します。
+func (l AssigneeList) names() []string {
+	names := make([]string, 0, len(l))
+	for _, a := range l {
+		names = append(names, a.Name)
+	}
+	return names
+}
+
+// containsAssignee は同じ名前・配分比率の担当者が l に含まれるかを返します。
+func containsAssignee(l AssigneeList, a Assignee) bool {
+	for _, x := range l {
+		if x == a {
+			return true
+		}
+	}
+	return false
+}
+
+// WeightShares は taskWeight を担当者の Share に応じて配分した結果を返します。
+func (l AssigneeList) WeightShares(taskWeight int) map[string]float64 {
+	shares := make(map[string]float64, len(l))
+	total := 0.0
+	for _, a := range l {
+		total += a.effectiveShare()
+	}
+	if total == 0 {
+		return shares
+	}
+	for _, a := range l {
+		shares[a.Name] += float64(taskWeight) * a.effectiveShare() / total
+	}
+	return shares
+}
+
+func (a Assignee) effectiveShare() float64 {
+	if a.Share <= 0 {
+		return 1
+	}
+	return a.Share
+}
+
+// applyAssignArgs は assign コマンドの引数を担当者一覧に適用します。
+//
+//	name / +name   担当者を追加（既にいれば何もしない）
+//	name:2         配分比率 2 で追加（既にいれば比率を更新）
+//	-name          担当者を削除
+//
+// 引数が空の場合は全員を外します。
+func applyAssignArgs(l AssigneeList, args []string) (AssigneeList, error) {
+	if len(args) == 0 {
+		return AssigneeList{}, nil
+	}
+
+	result := append(AssigneeList{}, l...)
+	for _, arg := range args {
+		if strings.HasPrefix(arg, "-") {
+			name := strings.TrimPrefix(arg, "-")
+			filtered := AssigneeList{}
+			for _, a := range result {
+				if a.Name != name {
+					filtered = append(filtered, a)
+				}
+			}
+			result = filtered
+			continue
+		}
+
+		name := strings.TrimPrefix(arg, "+")
+		share := 0.0
+		if i := strings.LastIndex(name, ":"); i >= 0 {
+			v, err := strconv.ParseFloat(name[i+1:], 64)
+			if err != nil || v <= 0 {
+				return nil, fmt.Errorf("配分比率は正の数値で指定してください: %s", arg)
+			}
+			name, share = name[:i], v
+		}
+		if name == "" {
+			return nil, fmt.Errorf("担当者名が空です: %s", arg)
+		}
+		if err := checkText("担当者名", name); err != nil {
+			return nil, err
+		}
+
+		updated := false
+		for i, a := range result {
+			if a.Name == name {
+				if share > 0 {
+					result[i].Share = share
+				}
+				updated = true
+			}
+		}
+		if !updated {
+			result = append(result, Assignee{Name: name, Share: share})
+		}
+	}
+	return result, nil
+}
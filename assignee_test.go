@@ -0,0 +1,109 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"reflect"
+	"testing"
+)
+
+func TestAssigneeListLegacyJSON(t *testing.T) {
+	tests := []struct {
+		name string
+		json string
+		want AssigneeList
+	}{
+		{"旧形式の文字列", `"hanako"`, AssigneeList{{Name: "hanako"}}},
+		{"旧形式の前後の空白", `" hanako "`, AssigneeList{{Name: "hanako"}}},
+		{"旧形式の空文字は担当者なし", `""`, AssigneeList{}},
+		{"現在の形式", `[{"name":"hanako","share":2},{"name":"taro"}]`, AssigneeList{{Name: "hanako", Share: 2}, {Name: "taro"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got AssigneeList
+			if err := json.Unmarshal([]byte(tt.json), &got); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Unmarshal(%s) = %+v, want %+v", tt.json, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLegacyAssigneeRoundTrip(t *testing.T) {
+	// 旧形式のファイルを読み込み、migrate で書き戻すと担当者リストの形式になる
+	setupStore(t)
+	legacy := `[{"id":1,"title":"a","done":false,"sprint_number":1,"task_weight":4,"assignees":"hanako"},` +
+		`{"id":2,"title":"b","done":false,"assignees":""}]`
+	if err := os.WriteFile(store.Path(), []byte(legacy), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	mustRun(t, "migrate")
+
+	data, err := os.ReadFile(store.Path())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var saved []struct {
+		ID        int        `json:"id"`
+		Assignees []Assignee `json:"assignees"`
+	}
+	if err := json.Unmarshal(data, &saved); err != nil {
+		t.Fatalf("保存後の assignees が担当者リストではありません: %v\n%s", err, data)
+	}
+	if len(saved) != 2 || !reflect.DeepEqual(saved[0].Assignees, []Assignee{{Name: "hanako"}}) || len(saved[1].Assignees) != 0 {
+		t.Fatalf("保存後の担当者 = %+v", saved)
+	}
+	task := getTask(t, 1)
+	if got := task.Assignees.WeightShares(task.TaskWeight); got["hanako"] != 4 {
+		t.Errorf("hanako のウェイト = %v, want 4", got["hanako"])
+	}
+}
+
+func TestWeightShares(t *testing.T) {
+	tests := []struct {
+		name      string
+		assignees AssigneeList
+		weight    int
+		want      map[string]float64
+	}{
+		{"担当者なし", AssigneeList{}, 5, map[string]float64{}},
+		{"均等割り", AssigneeList{{Name: "a"}, {Name: "b"}}, 4, map[string]float64{"a": 2, "b": 2}},
+		{"配分比率", AssigneeList{{Name: "a", Share: 3}, {Name: "b"}}, 8, map[string]float64{"a": 6, "b": 2}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.assignees.WeightShares(tt.weight); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("WeightShares(%d) = %v, want %v", tt.weight, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestApplyAssignArgs(t *testing.T) {
+	start := AssigneeList{{Name: "hanako"}, {Name: "taro", Share: 2}}
+	tests := []struct {
+		name    string
+		args    []string
+		want    AssigneeList
+		wantErr bool
+	}{
+		{"追加", []string{"+jiro"}, AssigneeList{{Name: "hanako"}, {Name: "taro", Share: 2}, {Name: "jiro"}}, false},
+		{"削除", []string{"-hanako"}, AssigneeList{{Name: "taro", Share: 2}}, false},
+		{"配分比率の更新", []string{"hanako:3"}, AssigneeList{{Name: "hanako", Share: 3}, {Name: "taro", Share: 2}}, false},
+		{"引数なしは全員外す", nil, AssigneeList{}, false},
+		{"不正な配分比率", []string{"hanako:x"}, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := applyAssignArgs(start, tt.args)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("applyAssignArgs(%v) の err = %v", tt.args, err)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("applyAssignArgs(%v) = %+v, want %+v", tt.args, got, tt.want)
+			}
+		})
+	}
+}
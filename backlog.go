@@ -0,0 +1,271 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// スプリント番号が 0 のタスクはプロダクトバックログとして扱い、Rank の昇順に並べます。
+// Rank が 0（未設定）のタスクは末尾に ID 順で並びます。
+
+func isBacklog(t Task) bool {
+	return t.SprintNumber == 0
+}
+
+// backlogTasks はバックログのタスクを優先順位順に返します。
+func backlogTasks(tasks []Task) []Task {
+	backlog := []Task{}
+	for _, t := range tasks {
+		if isBacklog(t) {
+			backlog = append(backlog, t)
+		}
+	}
+	sort.SliceStable(backlog, func(i, j int) bool {
+		ri, rj := backlog[i].Rank, backlog[j].Rank
+		if (ri == 0) != (rj == 0) {
+			return ri != 0
+		}
+		if ri != rj {
+			return ri < rj
+		}
+		return backlog[i].ID < backlog[j].ID
+	})
+	return backlog
+}
+
+// nextBacklogRank はバックログの末尾に追加するときの Rank を返します。
+func nextBacklogRank(tasks []Task) int {
+	maxRank := 0
+	for _, t := range tasks {
+		if isBacklog(t) && t.Rank > maxRank {
+			maxRank = t.Rank
+		}
+	}
+	return maxRank + 1
+}
+
+// ShowBacklog はバックログを優先順位順に表示します。
+func ShowBacklog() error {
+	tasks, err := store.List()
+	if err != nil {
+		return err
+	}
+
+	table := &Table{Header: []string{"Rank", "ID", "Title", "Weight", "Assignees", "Status"}}
+	total := 0
+	for i, t := range backlogTasks(tasks) {
+		table.Append([]string{
+			strconv.Itoa(i + 1),
+			strconv.Itoa(t.ID),
+			t.Title,
+			strconv.Itoa(t.TaskWeight),
+			t.Assignees.String(),
+			t.Status,
+		})
+		total += t.TaskWeight
+	}
+	output.AddTable(table)
+	output.Printf("合計ウェイト: %d", total)
+	return nil
+}
+
+// RankTask はバックログのタスクを position 番目（1 始まり）に移動します。
+func RankTask(id int, position int) error {
+	return moveInBacklog(id, func(order []Task) (int, error) {
+		if position < 1 {
+			return 0, fmt.Errorf("順位は 1 以上で指定してください")
+		}
+		return position - 1, nil
+	})
+}
+
+// RankTaskRelative はバックログのタスクを other の直前（above）または直後（below）に移動します。
+func RankTaskRelative(id int, other int, above bool) error {
+	return moveInBacklog(id, func(order []Task) (int, error) {
+		for i, t := range order {
+			if t.ID == other {
+				if above {
+					return i, nil
+				}
+				return i + 1, nil
+			}
+		}
+		return 0, fmt.Errorf("タスク #%d はバックログにありません", other)
+	})
+}
+
+// moveInBacklog は対象タスクをバックログから取り出し、target が返す位置に差し込んで Rank を振り直します。
+func moveInBacklog(id int, target func(order []Task) (int, error)) error {
+	unlock, err := store.Lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	tasks, err := store.List()
+	if err != nil {
+		return err
+	}
+	backlog := backlogTasks(tasks)
+
+	order := make([]Task, 0, len(backlog))
+	var moving *Task
+	for i, t := range backlog {
+		if t.ID == id {
+			moving = &backlog[i]
+			continue
+		}
+		order = append(order, t)
+	}
+	if moving == nil {
+		return fmt.Errorf("タスク #%d はバックログにありません", id)
+	}
+
+	pos, err := target(order)
+	if err != nil {
+		return err
+	}
+	if pos > len(order) {
+		pos = len(order)
+	}
+	order = append(order[:pos], append([]Task{*moving}, order[pos:]...)...)
+
+	changed := false
+	for i, t := range order {
+		if t.Rank == i+1 {
+			continue
+		}
+		oldRank := t.Rank
+		t.Rank = i + 1
+		if err := store.Update(t); err != nil {
+			return err
+		}
+		changed = true
+		if t.ID == id {
+			recordEvent(t, "rank", "rank", strconv.Itoa(oldRank), strconv.Itoa(t.Rank))
+		}
+	}
+	// 複数のタスクの Rank を振り直すため、全タスクをまとめて undo できるように記録する
+	if changed {
+		after, err := store.List()
+		if err != nil {
+			return err
+		}
+		recordBulkOp(fmt.Sprintf("rank #%d", id), tasks, after)
+	}
+	output.Printf("タスク #%d をバックログの %d 番目に移動しました", id, pos+1)
+	return nil
+}
+
+// PlanSprint はバックログの上から順に、キャパシティに収まるだけのタスクをスプリントへ移します。
+// capacity が 0 の場合はスプリントに設定された担当者ごとのキャパシティの合計、
+// それもなければ直近のスプリントの完了実績の平均を使います。
+// 計画後に担当者のキャパシティを超える場合は警告し、capacity_policy が refuse なら何も計画しません。
+func PlanSprint(number int, capacity int) error {
+	unlock, err := store.Lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	if err := checkSprintOpen(number); err != nil {
+		return err
+	}
+	sp, err := store.GetSprint(number)
+	if err != nil {
+		return err
+	}
+	tasks, err := store.List()
+	if err != nil {
+		return err
+	}
+
+	if capacity == 0 {
+		for _, c := range sp.Capacity {
+			capacity += c
+		}
+	}
+	if capacity == 0 {
+		// スプリントに設定がなければ直近の実績（平均完了ウェイト）を使う
+		sprints, err := store.ListSprints()
+		if err != nil {
+			return err
+		}
+		if _, team, ok := historicalCapacity(tasks, sprints, number); ok && team >= 1 {
+			capacity = int(team)
+			output.Printf("直近の実績からキャパシティを %d とします", capacity)
+		}
+	}
+	if capacity == 0 {
+		return fmt.Errorf("キャパシティが設定されていません（--capacity で指定するか、sprint create --capacity で設定してください）")
+	}
+	committed := 0
+	for _, t := range tasks {
+		if t.SprintNumber == number {
+			committed += t.TaskWeight
+		}
+	}
+
+	// 計画するタスクを決める。見積もりのない（ウェイト 0 の）タスクはキャパシティを消費せずに
+	// いくらでも入ってしまうため計画せず、別に表示する
+	selected := []Task{}
+	unestimated := []string{}
+	for _, t := range backlogTasks(tasks) {
+		if t.TaskWeight == 0 {
+			unestimated = append(unestimated, fmt.Sprintf("#%d", t.ID))
+			continue
+		}
+		if committed+t.TaskWeight > capacity {
+			break // 優先順位を守るため、収まらないタスクが出たらそこで止める
+		}
+		selected = append(selected, t)
+		committed += t.TaskWeight
+	}
+
+	// 計画後の状態で担当者ごと・チーム全体のキャパシティを確認する（add / assign と同じ）
+	moving := make(map[int]bool)
+	seen := make(map[string]bool)
+	names := []string{}
+	for _, t := range selected {
+		moving[t.ID] = true
+		for _, name := range t.Assignees.names() {
+			if !seen[name] {
+				seen[name] = true
+				names = append(names, name)
+			}
+		}
+	}
+	plannedTasks := append([]Task(nil), tasks...)
+	for i := range plannedTasks {
+		if moving[plannedTasks[i].ID] {
+			plannedTasks[i].SprintNumber = number
+		}
+	}
+	violations, err := capacityViolations(plannedTasks, number, names, true)
+	if err != nil {
+		return err
+	}
+	if err := checkOverCapacity(violations); err != nil {
+		return err
+	}
+
+	for _, t := range selected {
+		before := t
+		t.SprintNumber = number
+		t.Rank = 0
+		if err := store.Update(t); err != nil {
+			return err
+		}
+		recordEvent(t, "plan", "sprint_number", "0", strconv.Itoa(number))
+		recordTaskOp(fmt.Sprintf("plan #%d", t.ID), t.ID, &before, &t)
+		output.Printf("#%d %s (%dpt) → スプリント %d", t.ID, t.Title, t.TaskWeight, number)
+	}
+	if len(unestimated) > 0 {
+		output.Warnf("見積もりのないタスクは計画しませんでした（estimate で見積もってください）: %s", strings.Join(unestimated, ", "))
+	}
+	planned := len(selected)
+	output.Printf("%d 件のタスクを計画しました（計画ウェイト %d / キャパシティ %d）", planned, committed, capacity)
+	return nil
+}
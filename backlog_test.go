@@ -0,0 +1,87 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+// backlogOrder はバックログのタスク ID を優先順位順に返します。
+func backlogOrder(t *testing.T) []int {
+	t.Helper()
+	ids := []int{}
+	for _, task := range backlogTasks(loadTasks(t)) {
+		ids = append(ids, task.ID)
+	}
+	return ids
+}
+
+func setupBacklog(t *testing.T) {
+	t.Helper()
+	setupStore(t,
+		Task{ID: 1, Title: "a", Status: "todo", TaskWeight: 1, Rank: 1},
+		Task{ID: 2, Title: "b", Status: "todo", TaskWeight: 1, Rank: 2},
+		Task{ID: 3, Title: "c", Status: "todo", TaskWeight: 1, Rank: 3},
+		Task{ID: 4, Title: "d", Status: "todo", TaskWeight: 1}, // Rank 未設定は末尾
+		Task{ID: 5, Title: "sprint", Status: "todo", TaskWeight: 1, SprintNumber: 1},
+	)
+}
+
+func TestRank(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		want []int
+	}{
+		{"先頭へ", []string{"3", "1"}, []int{3, 1, 2, 4}},
+		{"順位を超える位置は末尾", []string{"1", "10"}, []int{2, 3, 4, 1}},
+		{"Rank 未設定のタスク", []string{"4", "2"}, []int{1, 4, 2, 3}},
+		{"直前へ", []string{"4", "above", "2"}, []int{1, 4, 2, 3}},
+		{"直後へ", []string{"1", "below", "3"}, []int{2, 3, 1, 4}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setupBacklog(t)
+			mustRun(t, append([]string{"rank"}, tt.args...)...)
+			if got := backlogOrder(t); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("順序 = %v, want %v", got, tt.want)
+			}
+			for i, task := range backlogTasks(loadTasks(t)) {
+				if task.Rank != i+1 {
+					t.Errorf("#%d の Rank = %d, want %d", task.ID, task.Rank, i+1)
+				}
+			}
+		})
+	}
+}
+
+func TestRankRejectsNonBacklogTask(t *testing.T) {
+	for _, args := range [][]string{
+		{"rank", "5", "1"},
+		{"rank", "1", "above", "5"},
+		{"rank", "1", "0"},
+	} {
+		setupBacklog(t)
+		if res := run(t, args...); res.Err == nil {
+			t.Errorf("%v がエラーになりません", args)
+		}
+		if got := backlogOrder(t); !reflect.DeepEqual(got, []int{1, 2, 3, 4}) {
+			t.Errorf("%v で順序が変わりました: %v", args, got)
+		}
+	}
+}
+
+func TestUndoRank(t *testing.T) {
+	setupBacklog(t)
+	mustRun(t, "rank", "3", "1")
+	mustRun(t, "undo")
+	if got := backlogOrder(t); !reflect.DeepEqual(got, []int{1, 2, 3, 4}) {
+		t.Errorf("undo 後の順序 = %v, want [1 2 3 4]", got)
+	}
+	if task := getTask(t, 4); task.Rank != 0 {
+		t.Errorf("undo 後の #4 の Rank = %d, want 0", task.Rank)
+	}
+	mustRun(t, "redo")
+	if got := backlogOrder(t); !reflect.DeepEqual(got, []int{3, 1, 2, 4}) {
+		t.Errorf("redo 後の順序 = %v, want [3 1 2 4]", got)
+	}
+}
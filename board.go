@@ -0,0 +1,404 @@
+package main
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+// board は todo board のかんばんボード（全画面の TUI）です。ワークフローのステータスごとに列を並べ、
+// カードの移動・担当者の変更・編集・削除は CLI と同じコマンド（move / assign / edit など）を
+// execute で実行します。そのため検証・変更履歴・undo の扱いは CLI と変わりません。
+
+// boardReloadInterval は他のプロセス（CLI など）による変更を確認する間隔です。
+const boardReloadInterval = time.Second
+
+const boardHelp = "[yellow]←→[-] 列  [yellow]↑↓[-] カード  [yellow]</>[-] 移動  [yellow]Enter[-] 詳細  " +
+	"[yellow]a[-] 担当者  [yellow]e[-] 編集  [yellow]d[-] 削除  [yellow]u[-] 戻す  [yellow]q[-] 終了"
+
+type board struct {
+	app     *tview.Application
+	pages   *tview.Pages
+	columns []*tview.List
+	status  *tview.TextView // 最後に実行したコマンドの結果
+
+	sprint int    // 0 はすべてのタスク
+	label  string // 空はすべてのタスク
+	tasks  []Task // 最後に読み込んだタスク（変更の検知用）
+	cards  [][]Task
+	focus  int // フォーカスのある列
+}
+
+// ShowBoard はかんばんボードを表示します。sprint・label を指定するとそのタスクだけを表示します。
+func ShowBoard(sprint int, label string) error {
+	if sprint != 0 {
+		if _, err := store.GetSprint(sprint); err == ErrSprintNotFound {
+			return notFound(err, "スプリント %d は存在しません", sprint)
+		} else if err != nil {
+			return err
+		}
+	}
+
+	b := newBoard(sprint, label)
+	b.app.SetInputCapture(b.handleKey)
+	b.reload(true)
+
+	stop := make(chan struct{})
+	defer close(stop)
+	go func() {
+		ticker := time.NewTicker(boardReloadInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				b.app.QueueUpdateDraw(func() { b.reload(false) })
+			}
+		}
+	}()
+
+	if err := b.app.SetRoot(b.pages, true).SetFocus(b.columns[b.focus]).EnableMouse(true).Run(); err != nil {
+		return fmt.Errorf("ボードを表示できませんでした: %w", err)
+	}
+	return nil
+}
+
+// newBoard はボードの画面を組み立てます。カードは reload で読み込みます。
+func newBoard(sprint int, label string) *board {
+	b := &board{
+		app:    tview.NewApplication(),
+		pages:  tview.NewPages(),
+		status: tview.NewTextView().SetDynamicColors(true),
+		sprint: sprint,
+		label:  label,
+	}
+	columns := tview.NewFlex()
+	for i := range workflow.Statuses {
+		col := i
+		list := tview.NewList().ShowSecondaryText(true).SetHighlightFullLine(true).SetSelectedFocusOnly(true)
+		list.SetBorder(true)
+		list.SetSelectedFunc(func(int, string, string, rune) { b.showDetail() })
+		list.SetFocusFunc(func() { b.focus = col; b.updateTitles() })
+		b.columns = append(b.columns, list)
+		columns.AddItem(list, 0, 1, i == 0)
+	}
+	help := tview.NewTextView().SetDynamicColors(true).SetText(boardHelp)
+	layout := tview.NewFlex().SetDirection(tview.FlexRow).
+		AddItem(columns, 0, 1, true).
+		AddItem(b.status, 2, 0, false).
+		AddItem(help, 1, 0, false)
+	b.pages.AddPage("board", layout, true, true)
+	return b
+}
+
+// reload はタスクを読み込み直して列を作り直します。force でなければ変更がない場合は何もしません。
+// 選択中のカードは、別の列へ移動していてもそのカードを選択したままにします。
+func (b *board) reload(force bool) {
+	tasks, err := store.List()
+	if err != nil {
+		b.showMessage(fmt.Sprintf("[red]タスクを読み込めませんでした: %s[-]", tview.Escape(err.Error())))
+		return
+	}
+	if !force && reflect.DeepEqual(tasks, b.tasks) {
+		return
+	}
+	selected, hasSelected := b.selected()
+	b.tasks = tasks
+
+	byID := tasksByID(tasks)
+	b.cards = make([][]Task, len(workflow.Statuses))
+	for _, t := range filterByLabel(tasks, b.label) {
+		if b.sprint != 0 && t.SprintNumber != b.sprint {
+			continue
+		}
+		for i, status := range workflow.Statuses {
+			if t.Status == status {
+				b.cards[i] = append(b.cards[i], t)
+			}
+		}
+	}
+
+	for i, list := range b.columns {
+		current := list.GetCurrentItem()
+		list.Clear()
+		for _, t := range b.cards[i] {
+			list.AddItem(cardTitle(t, byID), cardDetail(t), 0, nil)
+		}
+		list.SetCurrentItem(current)
+	}
+	if hasSelected {
+		for i, cards := range b.cards {
+			for j, t := range cards {
+				if t.ID == selected.ID {
+					b.columns[i].SetCurrentItem(j)
+					b.focusColumn(i)
+				}
+			}
+		}
+	}
+	b.updateTitles()
+}
+
+// cardTitle はカードの1行目（ID・タイトル・待っている依存先）です。
+func cardTitle(t Task, byID map[int]Task) string {
+	title := fmt.Sprintf("#%d %s", t.ID, tview.Escape(t.Title))
+	if ids := blockers(t, byID); len(ids) > 0 {
+		title += " [red](待ち: " + formatIDs(ids) + ")[-]"
+	}
+	return title
+}
+
+// cardDetail はカードの2行目（ウェイト・担当者・ラベル）です。担当者とラベルは ColorFromName の色で表示します。
+func cardDetail(t Task) string {
+	parts := []string{estimation.Label(t.TaskWeight) + "pt"}
+	if t.SprintNumber == 0 {
+		parts = append(parts, "backlog")
+	} else {
+		parts = append(parts, "sprint "+strconv.Itoa(t.SprintNumber))
+	}
+	for _, name := range t.Assignees.names() {
+		parts = append(parts, nameColorTag(name)+tview.Escape(name)+"[-]")
+	}
+	for _, l := range t.Labels {
+		parts = append(parts, nameColorTag(l)+"#"+tview.Escape(l)+"[-]")
+	}
+	return "  " + strings.Join(parts, " ")
+}
+
+// nameColorTag は名前から tview の色タグを作ります（グラフと同じ色になります）。
+func nameColorTag(name string) string {
+	c := ColorFromName(name)
+	return fmt.Sprintf("[#%02x%02x%02x]", c.R, c.G, c.B)
+}
+
+func (b *board) updateTitles() {
+	for i, list := range b.columns {
+		list.SetTitle(fmt.Sprintf(" %s (%d) ", workflow.Statuses[i], len(b.cards[i])))
+		if i == b.focus {
+			list.SetBorderColor(tcell.ColorYellow)
+		} else {
+			list.SetBorderColor(tview.Styles.BorderColor)
+		}
+	}
+}
+
+func (b *board) focusColumn(i int) {
+	if i < 0 || i >= len(b.columns) {
+		return
+	}
+	b.focus = i
+	b.app.SetFocus(b.columns[i])
+	b.updateTitles()
+}
+
+// selected はフォーカスのある列で選択中のカードを返します。
+func (b *board) selected() (Task, bool) {
+	if b.focus >= len(b.cards) || len(b.cards[b.focus]) == 0 {
+		return Task{}, false
+	}
+	i := b.columns[b.focus].GetCurrentItem()
+	if i < 0 || i >= len(b.cards[b.focus]) {
+		return Task{}, false
+	}
+	return b.cards[b.focus][i], true
+}
+
+func (b *board) handleKey(event *tcell.EventKey) *tcell.EventKey {
+	if name, _ := b.pages.GetFrontPage(); name != "board" {
+		return event // ダイアログの表示中はダイアログで処理する
+	}
+	switch event.Key() {
+	case tcell.KeyLeft:
+		if event.Modifiers()&tcell.ModShift != 0 {
+			b.moveCard(-1)
+		} else {
+			b.focusColumn(b.focus - 1)
+		}
+		return nil
+	case tcell.KeyRight:
+		if event.Modifiers()&tcell.ModShift != 0 {
+			b.moveCard(1)
+		} else {
+			b.focusColumn(b.focus + 1)
+		}
+		return nil
+	case tcell.KeyEscape:
+		b.app.Stop()
+		return nil
+	case tcell.KeyRune:
+	default:
+		return event
+	}
+
+	switch event.Rune() {
+	case 'h':
+		b.focusColumn(b.focus - 1)
+	case 'l':
+		b.focusColumn(b.focus + 1)
+	case 'j':
+		return tcell.NewEventKey(tcell.KeyDown, 0, tcell.ModNone)
+	case 'k':
+		return tcell.NewEventKey(tcell.KeyUp, 0, tcell.ModNone)
+	case '<', 'H':
+		b.moveCard(-1)
+	case '>', 'L':
+		b.moveCard(1)
+	case 'a':
+		b.showAssignDialog()
+	case 'e':
+		b.showEditDialog()
+	case 'd':
+		b.showDeleteDialog()
+	case 'u':
+		b.run("undo")
+	case 'q':
+		b.app.Stop()
+	default:
+		return event
+	}
+	return nil
+}
+
+// run は CLI と同じコマンドを実行し、結果を表示してボードを読み込み直します。
+func (b *board) run(args ...string) bool {
+	res := execute(args, frontendTUI, nil)
+	b.status.Clear()
+	writeResultTUI(b.status, res)
+	if res.Err == nil && len(res.Entries) == 0 {
+		fmt.Fprint(b.status, "[green]完了: "+tview.Escape(strings.Join(args, " "))+"[-]")
+	}
+	b.status.ScrollToEnd()
+	b.reload(true)
+	return res.Err == nil
+}
+
+func (b *board) showMessage(text string) {
+	b.status.SetText(text)
+}
+
+// moveCard は選択中のカードを隣の列（ステータス）へ移動します。
+func (b *board) moveCard(delta int) {
+	t, ok := b.selected()
+	to := b.focus + delta
+	if !ok || to < 0 || to >= len(workflow.Statuses) {
+		return
+	}
+	b.run("move", strconv.Itoa(t.ID), workflow.Statuses[to])
+}
+
+// showDialog は p をボードの中央に重ねて表示します。
+func (b *board) showDialog(p tview.Primitive, width, height int) {
+	dialog := tview.NewFlex().
+		AddItem(nil, 0, 1, false).
+		AddItem(tview.NewFlex().SetDirection(tview.FlexRow).
+			AddItem(nil, 0, 1, false).
+			AddItem(p, height, 0, true).
+			AddItem(nil, 0, 1, false), width, 0, true).
+		AddItem(nil, 0, 1, false)
+	b.pages.AddPage("dialog", dialog, true, true)
+	b.app.SetFocus(p)
+}
+
+func (b *board) closeDialog() {
+	focus := b.focus // ページを閉じると先頭の列にフォーカスが移るため、元の列に戻す
+	b.pages.RemovePage("dialog")
+	b.focusColumn(focus)
+}
+
+// showDetail は選択中のカードの詳細（show と同じ内容）を表示します。
+func (b *board) showDetail() {
+	t, ok := b.selected()
+	if !ok {
+		return
+	}
+	res := execute([]string{"show", strconv.Itoa(t.ID)}, frontendTUI, nil)
+	view := tview.NewTextView().SetDynamicColors(true).SetScrollable(true)
+	writeResultTUI(view, res)
+	view.SetBorder(true).SetTitle(" #" + strconv.Itoa(t.ID) + " （Esc で閉じる） ")
+	view.SetDoneFunc(func(tcell.Key) { b.closeDialog() })
+	b.showDialog(view, 80, 20)
+}
+
+// showAssignDialog は担当者を assign と同じ書式（+name -name name:share）で変更するダイアログを表示します。
+func (b *board) showAssignDialog() {
+	t, ok := b.selected()
+	if !ok {
+		return
+	}
+	form := tview.NewForm().
+		AddTextView("現在", orDash(t.Assignees.String()), 40, 1, false, false).
+		AddInputField("変更", "", 40, nil, nil)
+	form.AddButton("OK", func() {
+		args := strings.Fields(form.GetFormItemByLabel("変更").(*tview.InputField).GetText())
+		b.closeDialog()
+		b.run(append([]string{"assign", strconv.Itoa(t.ID)}, args...)...)
+	}).AddButton("キャンセル", b.closeDialog).SetCancelFunc(b.closeDialog)
+	form.SetBorder(true).SetTitle(fmt.Sprintf(" #%d の担当者（+name で追加 / -name で外す / 空で全員外す） ", t.ID))
+	b.showDialog(form, 70, 9)
+}
+
+// showEditDialog はタイトル・ウェイト・説明を編集するダイアログを表示します。変更した項目だけを1つの操作として保存します。
+func (b *board) showEditDialog() {
+	t, ok := b.selected()
+	if !ok {
+		return
+	}
+	weight := ""
+	if t.TaskWeight != 0 {
+		weight = estimation.Label(t.TaskWeight)
+	}
+	form := tview.NewForm().
+		AddInputField("タイトル", t.Title, 50, nil, nil).
+		AddInputField("ウェイト", weight, 10, nil, nil).
+		AddTextArea("説明", t.Description, 50, 5, 0, nil)
+	form.AddButton("保存", func() {
+		title := form.GetFormItemByLabel("タイトル").(*tview.InputField).GetText()
+		newWeight := strings.TrimSpace(form.GetFormItemByLabel("ウェイト").(*tview.InputField).GetText())
+		description := form.GetFormItemByLabel("説明").(*tview.TextArea).GetText()
+		b.closeDialog()
+		// 変更した項目を edit でまとめて保存する（undo も1回で戻る）
+		args := []string{"edit", strconv.Itoa(t.ID)}
+		if title != t.Title {
+			args = append(args, "--title", title)
+		}
+		if newWeight == "" {
+			newWeight = "0" // 空欄は未見積もり
+		}
+		if newWeight != weight && !(weight == "" && newWeight == "0") {
+			args = append(args, "--weight", newWeight)
+		}
+		if description != t.Description {
+			args = append(args, "--desc", description)
+		}
+		if len(args) > 2 {
+			b.run(args...)
+		}
+	}).AddButton("キャンセル", b.closeDialog).SetCancelFunc(b.closeDialog)
+	form.SetBorder(true).SetTitle(fmt.Sprintf(" #%d の編集 ", t.ID))
+	b.showDialog(form, 70, 15)
+}
+
+// showDeleteDialog は削除の確認ダイアログを表示します。
+func (b *board) showDeleteDialog() {
+	t, ok := b.selected()
+	if !ok {
+		return
+	}
+	modal := tview.NewModal().
+		SetText(fmt.Sprintf("#%d %s を削除しますか？（u で元に戻せます）", t.ID, tview.Escape(t.Title))).
+		AddButtons([]string{"削除", "キャンセル"}).
+		SetDoneFunc(func(_ int, label string) {
+			b.closeDialog()
+			if label == "削除" {
+				b.run("delete", strconv.Itoa(t.ID))
+			}
+		})
+	b.pages.AddPage("dialog", modal, true, true)
+	b.app.SetFocus(modal)
+}
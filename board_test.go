@@ -0,0 +1,147 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+var boardTasks = []Task{
+	{ID: 1, Title: "a", Status: "todo", TaskWeight: 1, SprintNumber: 1},
+	{ID: 2, Title: "b", Status: "todo", TaskWeight: 2, SprintNumber: 1, Labels: []string{"ui"}},
+	{ID: 3, Title: "c", Status: "doing", TaskWeight: 3, SprintNumber: 1, Labels: []string{"ui"}},
+	{ID: 4, Title: "d", Status: "done", TaskWeight: 5, SprintNumber: 2},
+	{ID: 5, Title: "e", Status: "blocked", TaskWeight: 8, Labels: []string{"ui"}},
+}
+
+// boardColumns は列ごとのカードの ID です。
+func boardColumns(b *board) [][]int {
+	columns := make([][]int, len(b.cards))
+	for i, cards := range b.cards {
+		columns[i] = []int{}
+		for _, t := range cards {
+			columns[i] = append(columns[i], t.ID)
+		}
+	}
+	return columns
+}
+
+func TestBoardReload(t *testing.T) {
+	tests := []struct {
+		name   string
+		sprint int
+		label  string
+		want   [][]int // todo, doing, review, done, blocked
+	}{
+		{"すべて", 0, "", [][]int{{1, 2}, {3}, {}, {4}, {5}}},
+		{"スプリント", 1, "", [][]int{{1, 2}, {3}, {}, {}, {}}},
+		{"ラベル", 0, "ui", [][]int{{2}, {3}, {}, {}, {5}}},
+		{"スプリントとラベル", 1, "ui", [][]int{{2}, {3}, {}, {}, {}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setupStore(t, boardTasks...)
+			b := newBoard(tt.sprint, tt.label)
+			b.reload(true)
+			if got := boardColumns(b); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("列 = %v, want %v", got, tt.want)
+			}
+			for i, list := range b.columns {
+				if list.GetItemCount() != len(tt.want[i]) {
+					t.Errorf("%s 列の項目数 = %d, want %d", workflow.Statuses[i], list.GetItemCount(), len(tt.want[i]))
+				}
+			}
+		})
+	}
+}
+
+func TestBoardReloadDetectsChanges(t *testing.T) {
+	setupStore(t, boardTasks...)
+	b := newBoard(0, "")
+	b.reload(true)
+
+	// 他のプロセスによる変更は次の reload で反映される
+	task := getTask(t, 1)
+	task.Status = "doing"
+	if err := store.Update(task); err != nil {
+		t.Fatal(err)
+	}
+	b.reload(false)
+	if got := boardColumns(b)[1]; !reflect.DeepEqual(got, []int{1, 3}) {
+		t.Errorf("doing 列 = %v, want [1 3]", got)
+	}
+}
+
+func TestBoardMoveKeepsSelection(t *testing.T) {
+	setupStore(t, boardTasks...)
+	b := newBoard(0, "")
+	b.reload(true)
+	b.columns[0].SetCurrentItem(1) // #2
+
+	b.moveCard(1)
+	if got := getTask(t, 2).Status; got != "doing" {
+		t.Fatalf("#2 のステータス = %s, want doing", got)
+	}
+	if sel, ok := b.selected(); !ok || sel.ID != 2 || b.focus != 1 {
+		t.Errorf("移動後の選択 = #%d（列 %d）, want #2（列 1）", sel.ID, b.focus)
+	}
+
+	b.moveCard(-1)
+	if sel, ok := b.selected(); !ok || sel.ID != 2 || b.focus != 0 || getTask(t, 2).Status != "todo" {
+		t.Errorf("戻した後の選択 = #%d（列 %d）, want #2（列 0）", sel.ID, b.focus)
+	}
+}
+
+func TestBoardMoveAtEdges(t *testing.T) {
+	setupStore(t, boardTasks...)
+	b := newBoard(0, "")
+	b.reload(true)
+
+	b.moveCard(-1) // 最初の列（todo）から左へは移動しない
+	last := len(workflow.Statuses) - 1
+	b.focusColumn(last)
+	b.moveCard(1) // 最後の列（blocked）から右へは移動しない
+	b.focusColumn(2)
+	b.moveCard(1) // 空の列では何もしない
+
+	for _, want := range boardTasks {
+		if got := getTask(t, want.ID).Status; got != want.Status {
+			t.Errorf("#%d のステータス = %s, want %s", want.ID, got, want.Status)
+		}
+	}
+	if j, err := loadJournal(); err != nil || len(j.Undo) != 0 {
+		t.Errorf("journal = %+v（err: %v）, want 操作なし", j, err)
+	}
+}
+
+func TestCardTitle(t *testing.T) {
+	byID := tasksByID([]Task{
+		{ID: 1, Status: "doing"},
+		{ID: 2, Status: "done"},
+	})
+	tests := []struct {
+		task Task
+		want string
+	}{
+		{Task{ID: 3, Title: "a"}, "#3 a"},
+		{Task{ID: 3, Title: "[red]a[-]"}, "#3 [red[]a[-[]"}, // タイトルの色タグは表示しない
+		{Task{ID: 3, Title: "a", DependsOn: []int{1, 2}}, "#3 a [red](待ち: #1)[-]"},
+	}
+	for _, tt := range tests {
+		if got := cardTitle(tt.task, byID); got != tt.want {
+			t.Errorf("cardTitle(%q) = %q, want %q", tt.task.Title, got, tt.want)
+		}
+	}
+}
+
+func TestCardDetail(t *testing.T) {
+	got := cardDetail(Task{ID: 1, TaskWeight: 3, SprintNumber: 2,
+		Assignees: AssigneeList{{Name: "taro"}, {Name: "[x]"}}, Labels: []string{"ui"}})
+	want := "  3pt sprint 2 " + nameColorTag("taro") + "taro[-] " + nameColorTag("[x]") + "[x[]" + "[-] " + nameColorTag("ui") + "#ui[-]"
+	if got != want {
+		t.Errorf("cardDetail = %q, want %q", got, want)
+	}
+	if got := cardDetail(Task{ID: 1}); !strings.HasPrefix(got, "  "+estimation.Label(0)+"pt backlog") {
+		t.Errorf("バックログのカード = %q", got)
+	}
+}
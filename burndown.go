@@ -0,0 +1,168 @@
+package main
+
+import (
+	"fmt"
+	"image/color"
+	"sort"
+	"time"
+
+	"gonum.org/v1/plot"
+	"gonum.org/v1/plot/plotter"
+	"gonum.org/v1/plot/vg"
+)
+
+// ShowBurndown はスプリントの残りタスクウェイトの推移を理想線とともに描画します。
+// sprint が 0 の場合は現在のスプリントを対象にします。出力形式は out の拡張子（.png / .svg）で決まります。
+func ShowBurndown(sprint int, out string) error {
+	settings, err := loadTimerSettings()
+	if err != nil {
+		return err
+	}
+	if settings == nil {
+		return fmt.Errorf("タイマー設定がありません。先に timersetting を実行してください。")
+	}
+	if sprint == 0 {
+		if sprint, err = currentSprint(); err != nil {
+			return err
+		}
+	}
+
+	tasks, err := store.List()
+	if err != nil {
+		return err
+	}
+	sprintTasks := []Task{}
+	total := 0
+	for _, t := range tasks {
+		if t.SprintNumber == sprint {
+			sprintTasks = append(sprintTasks, t)
+			total += t.TaskWeight
+		}
+	}
+	if len(sprintTasks) == 0 {
+		return fmt.Errorf("スプリント %d のタスクがありません。", sprint)
+	}
+
+	// ==== 1. タイムボックスの決定 ==========================================
+	run, ok := settings.Runs[sprint]
+	if !ok {
+		// timerstart の記録がない場合は現在の設定と最初の着手日時で代用
+		run = SprintRun{
+			Plannning:   settings.Plannning,
+			Development: settings.Development,
+			Review:      settings.Review,
+		}
+		for _, t := range sprintTasks {
+			for _, at := range []*time.Time{t.CreatedAt, t.StartedAt} {
+				if at != nil && (run.StartedAt.IsZero() || at.Before(run.StartedAt)) {
+					run.StartedAt = *at
+				}
+			}
+		}
+		if run.StartedAt.IsZero() {
+			return fmt.Errorf("スプリント %d の開始日時がわかりません（timerstart で記録されます）。", sprint)
+		}
+		output.Printf("スプリント %d の開始記録がないため、%s を開始とみなします。",
+			sprint, run.StartedAt.Local().Format("2006-01-02 15:04"))
+	}
+
+	// ==== 2. 残りウェイトの推移 ============================================
+	// タイムボックスとフェーズの区切りは、記録された実績（延長・スキップを反映）を使う
+	phases := run.phaseMinutes()
+	ideal, actual, xMax := burndownSeries(sprintTasks, run, time.Now())
+
+	// ==== 3. グラフ生成 ====================================================
+	p := plot.New()
+	p.Title.Text = fmt.Sprintf("Sprint %d Burndown", sprint)
+	p.X.Label.Text = "Elapsed (min)"
+	p.Y.Label.Text = "Remaining Weight"
+	p.X.Min = 0
+	p.X.Max = xMax
+	p.Y.Min = 0
+	p.Y.Max = float64(total)
+
+	idealLine, err := plotter.NewLine(ideal)
+	if err != nil {
+		return fmt.Errorf("グラフ生成に失敗しました: %w", err)
+	}
+	idealLine.Color = color.RGBA{150, 150, 150, 255}
+	idealLine.Dashes = []vg.Length{vg.Points(6), vg.Points(4)}
+
+	line, err := plotter.NewLine(actual)
+	if err != nil {
+		return fmt.Errorf("グラフ生成に失敗しました: %w", err)
+	}
+	line.StepStyle = plotter.PostStep
+	line.Color = color.RGBA{54, 162, 235, 255}
+	line.Width = vg.Points(2)
+
+	p.Add(plotter.NewGrid(), idealLine, line)
+	p.Legend.Add("Ideal", idealLine)
+	p.Legend.Add("Actual", line)
+	p.Legend.Top = true
+
+	// フェーズの区切り（計画終了・開発終了）
+	for _, m := range []float64{phases[0], phases[0] + phases[1]} {
+		sep, err := plotter.NewLine(plotter.XYs{{X: m, Y: 0}, {X: m, Y: float64(total)}})
+		if err != nil {
+			continue
+		}
+		sep.Color = color.RGBA{255, 159, 64, 255}
+		sep.Dashes = []vg.Length{vg.Points(2), vg.Points(2)}
+		p.Add(sep)
+	}
+
+	if err := p.Save(8*vg.Inch, 4*vg.Inch, out); err != nil {
+		return fmt.Errorf("グラフ画像の保存に失敗しました: %w", err)
+	}
+	output.Printf("バーンダウンチャート(%s)を出力しました。", out)
+	return nil
+}
+
+// burndownSeries はバーンダウンチャートの理想線と実績線の点、X 軸の最大値（分）を求めます。
+// 理想線はタイムボックスの開始から終了まで合計ウェイトを一定に減らし、実績線はタスクの完了ごとに減ります。
+func burndownSeries(tasks []Task, run SprintRun, now time.Time) (ideal, actual plotter.XYs, xMax float64) {
+	total := 0
+	for _, t := range tasks {
+		total += t.TaskWeight
+	}
+	phases := run.phaseMinutes()
+	timebox := phases[0] + phases[1] + phases[2] // 分
+	ideal = plotter.XYs{{X: 0, Y: float64(total)}, {X: timebox, Y: 0}}
+
+	type completion struct {
+		at     float64 // 開始からの経過分
+		weight int
+	}
+	completions := []completion{}
+	for _, t := range tasks {
+		if t.IsDone() && t.CompletedAt != nil {
+			completions = append(completions, completion{t.CompletedAt.Sub(run.StartedAt).Minutes(), t.TaskWeight})
+		}
+	}
+	sort.Slice(completions, func(i, j int) bool { return completions[i].at < completions[j].at })
+
+	remaining := float64(total)
+	actual = plotter.XYs{{X: 0, Y: remaining}}
+	xMax = timebox
+	for _, c := range completions {
+		at := c.at
+		if at < 0 {
+			at = 0 // 開始前に完了したタスク
+		}
+		remaining -= float64(c.weight)
+		actual = append(actual, plotter.XY{X: at, Y: remaining})
+		if at > xMax {
+			xMax = at
+		}
+	}
+	// 現在時刻（またはタイムボックス終了）まで線を伸ばす
+	elapsed := now.Sub(run.StartedAt).Minutes()
+	if elapsed > xMax {
+		elapsed = xMax
+	}
+	if elapsed > actual[len(actual)-1].X {
+		actual = append(actual, plotter.XY{X: elapsed, Y: remaining})
+	}
+	return ideal, actual, xMax
+}
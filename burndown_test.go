@@ -0,0 +1,110 @@
+package main
+
+import (
+	"os"
+	"reflect"
+	"testing"
+	"time"
+
+	"gonum.org/v1/plot/plotter"
+)
+
+func TestBurndownSeries(t *testing.T) {
+	start := time.Date(2024, 5, 1, 9, 0, 0, 0, time.UTC)
+	at := func(min int) *time.Time { return timePtr(start.Add(time.Duration(min) * time.Minute)) }
+	run := SprintRun{StartedAt: start, Plannning: 10, Development: 60, Review: 20} // タイムボックスは 90 分
+
+	tests := []struct {
+		name       string
+		tasks      []Task
+		run        SprintRun
+		now        time.Time
+		wantIdeal  plotter.XYs
+		wantActual plotter.XYs
+		wantXMax   float64
+	}{
+		{
+			name: "完了順に減り、現在時刻まで伸びる",
+			tasks: []Task{
+				{ID: 1, Status: "done", TaskWeight: 3, CompletedAt: at(40)},
+				{ID: 2, Status: "done", TaskWeight: 2, CompletedAt: at(20)},
+				{ID: 3, Status: "doing", TaskWeight: 5},
+			},
+			run:        run,
+			now:        *at(50),
+			wantIdeal:  plotter.XYs{{X: 0, Y: 10}, {X: 90, Y: 0}},
+			wantActual: plotter.XYs{{X: 0, Y: 10}, {X: 20, Y: 8}, {X: 40, Y: 5}, {X: 50, Y: 5}},
+			wantXMax:   90,
+		},
+		{
+			name: "開始前の完了は 0 分、タイムボックス後の完了で X 軸が伸びる",
+			tasks: []Task{
+				{ID: 1, Status: "done", TaskWeight: 1, CompletedAt: at(-30)},
+				{ID: 2, Status: "done", TaskWeight: 1, CompletedAt: at(120)},
+			},
+			run:        run,
+			now:        *at(500),
+			wantIdeal:  plotter.XYs{{X: 0, Y: 2}, {X: 90, Y: 0}},
+			wantActual: plotter.XYs{{X: 0, Y: 2}, {X: 0, Y: 1}, {X: 120, Y: 0}},
+			wantXMax:   120,
+		},
+		{
+			name:       "現在時刻はタイムボックスの終了まで",
+			tasks:      []Task{{ID: 1, Status: "todo", TaskWeight: 4}},
+			run:        run,
+			now:        *at(500),
+			wantIdeal:  plotter.XYs{{X: 0, Y: 4}, {X: 90, Y: 0}},
+			wantActual: plotter.XYs{{X: 0, Y: 4}, {X: 90, Y: 4}},
+			wantXMax:   90,
+		},
+		{
+			name:  "記録された実績（スキップ・延長）で理想線の終点が決まる",
+			tasks: []Task{{ID: 1, Status: "todo", TaskWeight: 4}},
+			run: SprintRun{StartedAt: start, Plannning: 10, Development: 60, Review: 20, Phases: []PhaseRun{
+				{Planned: 10, Actual: 5 * 60, Skipped: true},
+				{Planned: 70, Actual: 70 * 60},
+				{Planned: 20, Actual: 15 * 60},
+			}},
+			now:        start,
+			wantIdeal:  plotter.XYs{{X: 0, Y: 4}, {X: 90, Y: 0}},
+			wantActual: plotter.XYs{{X: 0, Y: 4}},
+			wantXMax:   90,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ideal, actual, xMax := burndownSeries(tt.tasks, tt.run, tt.now)
+			if !reflect.DeepEqual(ideal, tt.wantIdeal) {
+				t.Errorf("理想線 = %v, want %v", ideal, tt.wantIdeal)
+			}
+			if !reflect.DeepEqual(actual, tt.wantActual) {
+				t.Errorf("実績線 = %v, want %v", actual, tt.wantActual)
+			}
+			if xMax != tt.wantXMax {
+				t.Errorf("X 軸の最大値 = %v, want %v", xMax, tt.wantXMax)
+			}
+		})
+	}
+}
+
+func TestShowBurndown(t *testing.T) {
+	setupStore(t,
+		Task{ID: 1, Title: "a", Status: "done", TaskWeight: 3, SprintNumber: 1, CompletedAt: timePtr(time.Now())},
+		Task{ID: 2, Title: "b", Status: "todo", TaskWeight: 2, SprintNumber: 1},
+	)
+	if res := run(t, "burndown", "1"); res.Err == nil {
+		t.Error("タイマー設定がなくても成功しました")
+	}
+
+	settings := &Timer{Plannning: 10, Development: 20, Review: 30, SprintNumber: 1}
+	if err := recordSprintRun(settings); err != nil {
+		t.Fatal(err)
+	}
+	mustRun(t, "burndown", "--out", "burndown.svg")
+	if info, err := os.Stat("burndown.svg"); err != nil || info.Size() == 0 {
+		t.Errorf("burndown.svg が出力されません: %v", err)
+	}
+	if res := run(t, "burndown", "2"); res.Err == nil {
+		t.Error("タスクのないスプリントで成功しました")
+	}
+}
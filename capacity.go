@@ -0,0 +1,197 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+)
+
+// capacityHistorySprints はキャパシティを実績から求めるときに使う直近のスプリント数です。
+const capacityHistorySprints = 3
+
+// capacityPolicy はキャパシティ超過時の扱いです（"warn": 警告のみ, "refuse": 変更しない）。
+// todo_config.json の "capacity_policy" で変更できます。
+var capacityPolicy = "warn"
+
+// capacityRow は担当者ごとのキャパシティと負荷です。Capacity が負の場合は不明を表します。
+type capacityRow struct {
+	Name     string
+	Capacity float64
+	Source   string // "sprint"（スプリントに設定）または "history"（実績の平均）
+	Load     float64
+}
+
+// sprintLoad はスプリントの担当者ごとの負荷と、スプリント全体の計画ウェイトを返します。
+func sprintLoad(tasks []Task, number int) (map[string]float64, float64) {
+	load := make(map[string]float64)
+	total := 0.0
+	for _, t := range tasks {
+		if t.SprintNumber != number {
+			continue
+		}
+		total += float64(t.TaskWeight)
+		for name, w := range t.Assignees.WeightShares(t.TaskWeight) {
+			load[name] += w
+		}
+	}
+	return load, total
+}
+
+// historicalCapacity は number より前の直近の終了したスプリントの完了実績から、
+// 担当者ごとの平均完了ウェイトとチーム全体の平均完了ウェイトを求めます。
+func historicalCapacity(tasks []Task, sprints []Sprint, number int) (map[string]float64, float64, bool) {
+	recent := []int{}
+	for _, v := range collectVelocity(tasks, sprints) {
+		if v.Sprint < number && v.Closed() {
+			recent = append(recent, v.Sprint)
+		}
+	}
+	if len(recent) == 0 {
+		return nil, 0, false
+	}
+	if len(recent) > capacityHistorySprints {
+		recent = recent[len(recent)-capacityHistorySprints:]
+	}
+	inRange := make(map[int]bool)
+	for _, n := range recent {
+		inRange[n] = true
+	}
+
+	person := make(map[string]float64)
+	team := 0.0
+	for _, t := range tasks {
+		if !inRange[t.SprintNumber] || !t.IsDone() {
+			continue
+		}
+		team += float64(t.TaskWeight)
+		for name, w := range t.Assignees.WeightShares(t.TaskWeight) {
+			person[name] += w
+		}
+	}
+	n := float64(len(recent))
+	for name := range person {
+		person[name] /= n
+	}
+	return person, team / n, true
+}
+
+// sprintCapacity は担当者ごとのキャパシティ・負荷と、チーム全体のキャパシティ・負荷を求めます。
+// スプリントに設定されたキャパシティを優先し、なければ実績の平均を使います。
+func sprintCapacity(tasks []Task, number int) ([]capacityRow, capacityRow, error) {
+	sp, err := store.GetSprint(number)
+	if err != nil && err != ErrSprintNotFound {
+		return nil, capacityRow{}, err
+	}
+	sprints, err := store.ListSprints()
+	if err != nil {
+		return nil, capacityRow{}, err
+	}
+	load, total := sprintLoad(tasks, number)
+	// 終了したスプリントから持ち越したタスクは次のスプリントに移っているため、終了時に記録した負荷を戻す
+	total += float64(sp.CarryOver.CarriedWeight)
+	for name, w := range sp.CarryOver.CarriedLoad {
+		load[name] += w
+	}
+	history, teamHistory, hasHistory := historicalCapacity(tasks, sprints, number)
+
+	names := make(map[string]bool)
+	for name := range sp.Capacity {
+		names[name] = true
+	}
+	for name := range load {
+		names[name] = true
+	}
+
+	rows := make([]capacityRow, 0, len(names))
+	teamCapacity := 0.0
+	for name := range names {
+		row := capacityRow{Name: name, Capacity: -1, Load: load[name]}
+		if c, ok := sp.Capacity[name]; ok {
+			row.Capacity, row.Source = float64(c), "sprint"
+			teamCapacity += float64(c)
+		} else if c, ok := history[name]; ok {
+			row.Capacity, row.Source = c, "history"
+		}
+		rows = append(rows, row)
+	}
+	sort.Slice(rows, func(i, j int) bool { return rows[i].Name < rows[j].Name })
+
+	team := capacityRow{Name: "(team)", Capacity: -1, Load: total}
+	if teamCapacity > 0 {
+		team.Capacity, team.Source = teamCapacity, "sprint"
+	} else if hasHistory {
+		team.Capacity, team.Source = teamHistory, "history"
+	}
+	return rows, team, nil
+}
+
+// capacityViolations は tasks の状態でスプリント number がキャパシティを超えていないか確認します。
+// names を指定した担当者と、checkTeam の場合はチーム全体を確認します。
+func capacityViolations(tasks []Task, number int, names []string, checkTeam bool) ([]string, error) {
+	if number == 0 {
+		return nil, nil // バックログにはキャパシティがない
+	}
+	rows, team, err := sprintCapacity(tasks, number)
+	if err != nil {
+		return nil, err
+	}
+	messages := []string{}
+	if checkTeam && team.Capacity >= 0 && team.Load > team.Capacity {
+		messages = append(messages, fmt.Sprintf("スプリント %d の計画ウェイト %.1f がキャパシティ %.1f を超えています", number, team.Load, team.Capacity))
+	}
+	for _, row := range rows {
+		for _, name := range names {
+			if row.Name == name && row.Capacity >= 0 && row.Load > row.Capacity {
+				messages = append(messages, fmt.Sprintf("%s のスプリント %d の担当ウェイト %.1f がキャパシティ %.1f を超えています", name, number, row.Load, row.Capacity))
+			}
+		}
+	}
+	return messages, nil
+}
+
+// checkOverCapacity はキャパシティ超過の警告を表示し、capacity_policy が refuse の場合はエラーを返します。
+func checkOverCapacity(messages []string) error {
+	if len(messages) == 0 {
+		return nil
+	}
+	for _, m := range messages {
+		output.Warnf("%s", m)
+	}
+	if capacityPolicy == "refuse" {
+		return fmt.Errorf("キャパシティを超えるため変更しませんでした（capacity_policy: refuse）")
+	}
+	return nil
+}
+
+// ShowCapacity はスプリントの担当者ごとの負荷とキャパシティを表示します。
+func ShowCapacity(number int) error {
+	if _, err := store.GetSprint(number); err == ErrSprintNotFound {
+		return notFound(err, "スプリント %d は存在しません", number)
+	} else if err != nil {
+		return err
+	}
+	tasks, err := store.List()
+	if err != nil {
+		return err
+	}
+	rows, team, err := sprintCapacity(tasks, number)
+	if err != nil {
+		return err
+	}
+
+	table := &Table{Header: []string{"Assignee", "Capacity", "Source", "Load", "Remaining", "Status"}}
+	for _, row := range append(rows, team) {
+		capacity, remaining, status := "-", "-", "-"
+		if row.Capacity >= 0 {
+			capacity = strconv.FormatFloat(row.Capacity, 'f', 1, 64)
+			remaining = strconv.FormatFloat(row.Capacity-row.Load, 'f', 1, 64)
+			status = "OK"
+			if row.Load > row.Capacity {
+				status = "OVER"
+			}
+		}
+		table.Append([]string{row.Name, capacity, orDash(row.Source), fmt.Sprintf("%.1f", row.Load), remaining, status})
+	}
+	output.AddTable(table)
+	return nil
+}
@@ -0,0 +1,163 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCapacityViolations(t *testing.T) {
+	sprint := Sprint{Number: 2, State: SprintActive, Capacity: map[string]int{"taro": 5, "hanako": 3}}
+	tests := []struct {
+		name      string
+		tasks     []Task
+		names     []string
+		checkTeam bool
+		want      int
+	}{
+		{
+			name:      "キャパシティ内",
+			tasks:     []Task{{ID: 1, Status: "todo", SprintNumber: 2, TaskWeight: 5, Assignees: AssigneeList{{Name: "taro"}}}},
+			names:     []string{"taro"},
+			checkTeam: true,
+		},
+		{
+			name:  "担当者の超過",
+			tasks: []Task{{ID: 1, Status: "todo", SprintNumber: 2, TaskWeight: 5, Assignees: AssigneeList{{Name: "hanako"}}}},
+			names: []string{"hanako"},
+			want:  1,
+		},
+		{
+			name:  "確認しない担当者の超過は数えない",
+			tasks: []Task{{ID: 1, Status: "todo", SprintNumber: 2, TaskWeight: 5, Assignees: AssigneeList{{Name: "hanako"}}}},
+			names: []string{"taro"},
+		},
+		{
+			name: "担当者とチーム全体の超過",
+			tasks: []Task{
+				{ID: 1, Status: "todo", SprintNumber: 2, TaskWeight: 6, Assignees: AssigneeList{{Name: "taro"}}},
+				{ID: 2, Status: "todo", SprintNumber: 2, TaskWeight: 3},
+			},
+			names:     []string{"taro"},
+			checkTeam: true,
+			want:      2,
+		},
+		{
+			name: "配分で負荷を分ける",
+			tasks: []Task{
+				{ID: 1, Status: "todo", SprintNumber: 2, TaskWeight: 8, Assignees: AssigneeList{{Name: "taro", Share: 5}, {Name: "hanako", Share: 3}}},
+			},
+			names:     []string{"taro", "hanako"},
+			checkTeam: true,
+		},
+		{
+			name:  "他のスプリントのタスクは数えない",
+			tasks: []Task{{ID: 1, Status: "todo", SprintNumber: 3, TaskWeight: 9, Assignees: AssigneeList{{Name: "taro"}}}},
+			names: []string{"taro"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setupStore(t)
+			if err := store.SaveSprint(sprint); err != nil {
+				t.Fatal(err)
+			}
+			got, err := capacityViolations(tt.tasks, 2, tt.names, tt.checkTeam)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(got) != tt.want {
+				t.Errorf("capacityViolations() = %q, want %d 件", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCapacityViolationsBacklog(t *testing.T) {
+	setupStore(t)
+	tasks := []Task{{ID: 1, Status: "todo", TaskWeight: 100, Assignees: AssigneeList{{Name: "taro"}}}}
+	if got, err := capacityViolations(tasks, 0, []string{"taro"}, true); err != nil || len(got) != 0 {
+		t.Errorf("バックログの capacityViolations() = %q, %v, want なし", got, err)
+	}
+}
+
+func TestCapacityFromHistory(t *testing.T) {
+	// スプリントにキャパシティがなければ、終了したスプリントの完了実績の平均を使う
+	setupStore(t,
+		Task{ID: 1, Status: "done", SprintNumber: 1, TaskWeight: 4, Assignees: AssigneeList{{Name: "taro"}}},
+		Task{ID: 2, Status: "todo", SprintNumber: 2, TaskWeight: 5, Assignees: AssigneeList{{Name: "taro"}}},
+	)
+	for _, sp := range []Sprint{{Number: 1, State: SprintClosed}, {Number: 2, State: SprintActive}} {
+		if err := store.SaveSprint(sp); err != nil {
+			t.Fatal(err)
+		}
+	}
+	rows, team, err := sprintCapacity(loadTasks(t), 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []capacityRow{{Name: "taro", Capacity: 4, Source: "history", Load: 5}}
+	if !reflect.DeepEqual(rows, want) {
+		t.Errorf("rows = %+v, want %+v", rows, want)
+	}
+	if team.Capacity != 4 || team.Source != "history" {
+		t.Errorf("team = %+v, want 実績 4", team)
+	}
+}
+
+func TestCapacityPolicy(t *testing.T) {
+	tests := []struct {
+		policy    string
+		wantErr   bool
+		wantTasks int
+	}{
+		{"warn", false, 2},
+		{"refuse", true, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.policy, func(t *testing.T) {
+			setupStore(t, Task{ID: 1, Title: "a", Status: "todo", SprintNumber: 1, TaskWeight: 3, Assignees: AssigneeList{{Name: "taro"}}})
+			if err := store.SaveSprint(Sprint{Number: 1, State: SprintActive, Capacity: map[string]int{"taro": 4}}); err != nil {
+				t.Fatal(err)
+			}
+			saved := capacityPolicy
+			capacityPolicy = tt.policy
+			t.Cleanup(func() { capacityPolicy = saved })
+
+			res := run(t, "add", "b", "1", "2")
+			if (res.Err != nil) != tt.wantErr {
+				t.Errorf("add の err = %v, wantErr %v", res.Err, tt.wantErr)
+			}
+			if got := len(loadTasks(t)); got != tt.wantTasks {
+				t.Errorf("タスク数 = %d, want %d", got, tt.wantTasks)
+			}
+			warned := false
+			for _, e := range res.Entries {
+				warned = warned || e.Kind == EntryWarning
+			}
+			if !warned {
+				t.Error("キャパシティ超過の警告がありません")
+			}
+		})
+	}
+}
+
+func TestCapacityPolicyRefusesAssign(t *testing.T) {
+	setupStore(t,
+		Task{ID: 1, Title: "a", Status: "todo", SprintNumber: 1, TaskWeight: 3, Assignees: AssigneeList{{Name: "taro"}}},
+		Task{ID: 2, Title: "b", Status: "todo", SprintNumber: 1, TaskWeight: 2},
+	)
+	if err := store.SaveSprint(Sprint{Number: 1, State: SprintActive, Capacity: map[string]int{"taro": 4, "hanako": 10}}); err != nil {
+		t.Fatal(err)
+	}
+	saved := capacityPolicy
+	capacityPolicy = "refuse"
+	t.Cleanup(func() { capacityPolicy = saved })
+
+	if res := run(t, "assign", "2", "+taro"); res.Err == nil {
+		t.Error("キャパシティを超える担当者の追加がエラーになりません")
+	}
+	if task := getTask(t, 2); len(task.Assignees) != 0 {
+		t.Errorf("#2 の担当者 = %s, want なし", task.Assignees)
+	}
+	mustRun(t, "assign", "2", "+hanako")
+}
@@ -0,0 +1,364 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/kballard/go-shellquote"
+)
+
+// コマンドは commands（commands.go）の表で定義し、CLI（main）・スプリントコンソール（listenInput）・
+// TUI（handleTUIViewCommand）で同じ表を使って解釈・実行します。どの画面も execute が返す Result（result.go）を
+// 表示するだけで、検証やエラーの扱いはここに集約します。
+
+// 終了コード
+const (
+	exitOK       = 0
+	exitFailure  = 1 // 操作できなかった（不正なステータス遷移、キャパシティ超過など）
+	exitUsage    = 2 // 引数やオプションの誤り
+	exitNotFound = 3 // 指定したタスク・スプリント・エピックが存在しない
+)
+
+// command は1つのコマンド（またはサブコマンドのグループ）の定義です。
+type command struct {
+	Name    string
+	Args    string // 位置引数の書式（ヘルプ表示用）
+	Summary string
+	MinArgs int
+	MaxArgs int // 位置引数の上限（anyArgs は上限なし）
+	// RawArgs の場合はオプションを解釈せず、引数をそのまま渡します（-name のような引数を受け付けるため）。
+	RawArgs bool
+	// Interactive のコマンドは標準入力から応答を読むため、TUI では AnswerFlags のオプションを
+	// すべて指定した場合（対話が不要な場合）だけ使えます。
+	Interactive bool
+	// AnswerFlags は Interactive のコマンドで、対話の代わりに応答を指定するオプションの名前です。
+	AnswerFlags []string
+	// CLIOnly のコマンドはスプリントコンソールと TUI では使えません。
+	CLIOnly bool
+	// TimerOnly のコマンドはスプリントタイマーの実行中（スプリントコンソールと TUI）だけで使えます。
+	TimerOnly bool
+	// Setup は fs にオプションを定義し、位置引数を受け取って実行する関数を返します。
+	// 実行のたびに呼ばれるため、前回のオプションの値が残ることはありません。
+	Setup func(fs *flag.FlagSet) func(args []string) error
+	Sub   []*command // サブコマンド（sprint create など）
+}
+
+const anyArgs = -1
+
+// frontend はコマンドを実行する画面です。
+type frontend int
+
+const (
+	frontendCLI frontend = iota
+	frontendConsole
+	frontendTUI
+)
+
+// usageError は引数やオプションの誤りです。表示時にコマンドの書式を添えます。
+type usageError struct {
+	msg   string
+	usage string
+}
+
+func (e *usageError) Error() string { return e.msg }
+
+func usageErrorf(format string, a ...interface{}) error {
+	return &usageError{msg: fmt.Sprintf(format, a...)}
+}
+
+// notFoundError は対象が存在しないことを表すエラーです。errors.Is で ErrTaskNotFound などと比較できます。
+type notFoundError struct {
+	msg string
+	err error
+}
+
+func (e *notFoundError) Error() string { return e.msg }
+func (e *notFoundError) Unwrap() error { return e.err }
+
+// notFound は err（ErrSprintNotFound など）を元にしたメッセージ付きのエラーを返します。
+func notFound(err error, format string, a ...interface{}) error {
+	return &notFoundError{msg: fmt.Sprintf(format, a...), err: err}
+}
+
+// exitCode はコマンドの結果を終了コードに変換します。
+func exitCode(err error) int {
+	var usage *usageError
+	switch {
+	case err == nil:
+		return exitOK
+	case errors.As(err, &usage):
+		return exitUsage
+	case errors.Is(err, ErrTaskNotFound), errors.Is(err, ErrSprintNotFound), errors.Is(err, ErrEpicNotFound):
+		return exitNotFound
+	default:
+		return exitFailure
+	}
+}
+
+// printError はコマンドのエラーを表示します。引数の誤りの場合は書式も表示します。
+func printError(w io.Writer, err error) {
+	fmt.Fprintln(w, err)
+	var usage *usageError
+	if errors.As(err, &usage) && usage.usage != "" {
+		fmt.Fprintln(w, usage.usage)
+	}
+}
+
+// execute は args[0] のコマンドを実行し、出力とエラーを Result にまとめて返します。
+// live を指定すると出力を逐次受け取れます（poker などの対話と表示の順序を保つため）。
+func execute(args []string, fe frontend, live func(Entry)) *Result {
+	run := func() error {
+		return runCommand(args, fe)
+	}
+	if fe == frontendCLI {
+		// CLI は1度に1つのコマンドしか実行しないため排他しません
+		// （timerstart の実行中にスプリントコンソールのコマンドを実行できるようにするため）。
+		return capture(live, run)
+	}
+	return collect(live, run)
+}
+
+// executeLine はスプリントコンソールや TUI に入力された1行を実行します。
+// 空白を含む引数は "..." または '...' で囲みます。
+func executeLine(line string, fe frontend, live func(Entry)) *Result {
+	args, err := shellquote.Split(line)
+	if err != nil {
+		return &Result{Err: usageErrorf("コマンドを解釈できません: %v", err)}
+	}
+	if len(args) == 0 {
+		return &Result{}
+	}
+	return execute(args, fe, live)
+}
+
+func runCommand(args []string, fe frontend) error {
+	prefix := "todo"
+	if fe != frontendCLI {
+		prefix = "" // コンソールではコマンド名だけを入力する
+	}
+	return dispatch(commands, prefix, args, fe)
+}
+
+func dispatch(cmds []*command, prefix string, args []string, fe frontend) error {
+	if len(args) == 0 {
+		printCommandList(cmds, prefix, fe)
+		return usageErrorf("コマンドを指定してください")
+	}
+	name := args[0]
+	if isHelpArg(name) || name == "help" {
+		if name == "help" && len(args) > 1 {
+			return dispatch(cmds, prefix, []string{args[1], "--help"}, fe)
+		}
+		printCommandList(cmds, prefix, fe)
+		return nil
+	}
+	c := findCommand(cmds, name)
+	if c == nil || !c.availableIn(fe) {
+		if c != nil && c.TimerOnly {
+			return fmt.Errorf("%s はスプリントタイマーの実行中（timerstart）に使います", name)
+		}
+		if c != nil {
+			return fmt.Errorf("%s はここでは使えません", name)
+		}
+		return usageErrorf("不明なコマンドです: %s（%s で一覧を表示します）", name, strings.TrimSpace(prefix+" help"))
+	}
+	path := strings.TrimSpace(prefix + " " + c.Name)
+	if c.Sub != nil {
+		return dispatch(c.Sub, path, args[1:], fe)
+	}
+
+	fs := flag.NewFlagSet(path, flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	run := c.Setup(fs)
+	var rest []string
+	if c.RawArgs {
+		if len(args) > 1 && isHelpArg(args[1]) {
+			printCommandHelp(path, c, fs)
+			return nil
+		}
+		rest = args[1:]
+	} else {
+		var err error
+		rest, err = parseInterspersed(fs, args[1:])
+		if err == flag.ErrHelp {
+			printCommandHelp(path, c, fs)
+			return nil
+		}
+		if err != nil {
+			return &usageError{msg: err.Error(), usage: usageLine(path, c, fs)}
+		}
+	}
+	if fe == frontendTUI && c.Interactive {
+		for _, name := range c.AnswerFlags {
+			if !flagGiven(fs, name) {
+				return &usageError{msg: fmt.Sprintf("TUI では対話できないため --%s を指定してください", name), usage: usageLine(path, c, fs)}
+			}
+		}
+	}
+	if len(rest) < c.MinArgs || (c.MaxArgs != anyArgs && len(rest) > c.MaxArgs) {
+		return &usageError{msg: "引数の数が正しくありません", usage: usageLine(path, c, fs)}
+	}
+	err := run(rest)
+	var usage *usageError
+	if errors.As(err, &usage) && usage.usage == "" {
+		usage.usage = usageLine(path, c, fs)
+	}
+	return err
+}
+
+func (c *command) availableIn(fe frontend) bool {
+	switch fe {
+	case frontendConsole:
+		return !c.CLIOnly
+	case frontendTUI:
+		return !c.CLIOnly && (!c.Interactive || len(c.AnswerFlags) > 0)
+	}
+	return !c.TimerOnly
+}
+
+func findCommand(cmds []*command, name string) *command {
+	for _, c := range cmds {
+		if c.Name == name {
+			return c
+		}
+	}
+	return nil
+}
+
+func isHelpArg(arg string) bool {
+	return arg == "-h" || arg == "--help" || arg == "-help"
+}
+
+// parseInterspersed は位置引数の間にオプションがあっても解釈し、位置引数を返します。
+// （標準の flag パッケージは最初の位置引数で解釈を止めるため）
+func parseInterspersed(fs *flag.FlagSet, args []string) ([]string, error) {
+	positional := []string{}
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		rest := fs.Args()
+		// "--" より後ろはすべて位置引数として扱う（"-" で始まるタイトルなどを渡せるように）
+		if n := len(args) - len(rest); n > 0 && args[n-1] == "--" {
+			return append(positional, rest...), nil
+		}
+		if len(rest) == 0 {
+			return positional, nil
+		}
+		positional = append(positional, rest[0])
+		args = rest[1:]
+	}
+}
+
+func usageLine(path string, c *command, fs *flag.FlagSet) string {
+	line := "Usage: " + path
+	if c.Args != "" {
+		line += " " + c.Args
+	}
+	hasFlags := false
+	fs.VisitAll(func(*flag.Flag) { hasFlags = true })
+	if hasFlags {
+		line += " [options]"
+	}
+	return line
+}
+
+// printCommandHelp は --help の内容（書式・説明・オプション）を表示します。
+func printCommandHelp(path string, c *command, fs *flag.FlagSet) {
+	output.Println(usageLine(path, c, fs))
+	output.Println()
+	output.Println(c.Summary)
+	hasFlags := false
+	fs.VisitAll(func(*flag.Flag) { hasFlags = true })
+	if !hasFlags {
+		return
+	}
+	output.Println()
+	output.Println("Options:")
+	fs.VisitAll(func(f *flag.Flag) {
+		name, usage := flag.UnquoteUsage(f)
+		line := "  --" + f.Name
+		if name != "" {
+			line += " <" + name + ">"
+		}
+		if f.DefValue != "" && f.DefValue != "0" && f.DefValue != "false" && f.DefValue != "[]" {
+			usage += fmt.Sprintf("（省略時: %s）", f.DefValue)
+		}
+		output.Printf("%s\n        %s", line, usage)
+	})
+}
+
+// printCommandList はコマンドの一覧を表示します。
+func printCommandList(cmds []*command, prefix string, fe frontend) {
+	if fe == frontendCLI && prefix == "todo" {
+		output.Println("Usage: todo [--store json|sqlite] [--data <path>] [--actor <name>] <command> [arguments]")
+	} else {
+		output.Printf("Usage: %s", strings.TrimSpace(prefix+" <command> [arguments]"))
+	}
+	output.Println()
+	output.Println("Commands:")
+	width := 0
+	for _, c := range cmds {
+		if w := textWidth(c.Name); w > width {
+			width = w
+		}
+	}
+	for _, c := range cmds {
+		if c.availableIn(fe) {
+			output.Printf("  %s  %s", padRight(c.Name, width), c.Summary)
+		}
+	}
+	output.Println()
+	output.Printf("各コマンドの詳細は %s で表示します。", strings.TrimSpace(prefix+" <command> --help"))
+}
+
+// ==== 引数の解釈 ============================================================
+
+// parseID は位置引数のタスク ID などを正の整数として解釈します。
+func parseID(what string, s string) (int, error) {
+	n, err := strconv.Atoi(s)
+	if err != nil || n <= 0 {
+		return 0, usageErrorf("%sは正の整数で指定してください: %q", what, s)
+	}
+	return n, nil
+}
+
+// parseCount は 0 以上の整数を解釈します（0 はバックログ・未指定などの意味を持つ引数用）。
+func parseCount(what string, s string) (int, error) {
+	n, err := strconv.Atoi(s)
+	if err != nil || n < 0 {
+		return 0, usageErrorf("%sは 0 以上の整数で指定してください: %q", what, s)
+	}
+	return n, nil
+}
+
+// stringsFlag は複数回指定できる文字列のオプションです（--assignee hanako --assignee taro）。
+type stringsFlag []string
+
+func (s *stringsFlag) String() string { return strings.Join(*s, ",") }
+
+func (s *stringsFlag) Set(value string) error {
+	*s = append(*s, value)
+	return nil
+}
+
+// flagGiven は fs で name のオプションが指定されたかを返します。
+func flagGiven(fs *flag.FlagSet, name string) bool {
+	given := false
+	fs.Visit(func(f *flag.Flag) {
+		if f.Name == name {
+			given = true
+		}
+	})
+	return given
+}
+
+// stdin は対話的な入力に使う共通のスキャナです。スプリントコンソールとその中で実行した
+// poker などが別々にバッファを持つと入力を取りこぼすため、すべてこれを使います。
+var stdin = bufio.NewScanner(os.Stdin)
@@ -0,0 +1,684 @@
+package main
+
+import (
+	"flag"
+	"strings"
+	"time"
+)
+
+// commands はすべてのコマンドの定義です（cli.go で解釈・実行します）。
+// timerstart などのコマンドが内部で commands を参照するため、init で設定します。
+var commands []*command
+
+func init() {
+	commands = []*command{
+		{
+			Name: "add", Args: "<title> [<sprintNumber> <taskWeight>]", MinArgs: 1, MaxArgs: 3,
+			Summary: "タスクを追加します（スプリント番号 0 はバックログ、ウェイト 0 は未見積もり）",
+			Setup: func(fs *flag.FlagSet) func([]string) error {
+				sprint := fs.Int("sprint", 0, "追加するスプリントの`番号`（0 はバックログ）")
+				weight := fs.String("weight", "0", "タスクウェイト（見積もりスケールの`値`、0 は未見積もり）")
+				parent := fs.Int("parent", 0, "親タスクの`ID`（子タスクとして追加）")
+				epic := fs.Int("epic", 0, "所属するエピックの`ID`")
+				desc := fs.String("desc", "", "タスクの`説明`")
+				var assignees, labels stringsFlag
+				fs.Var(&assignees, "assignee", "担当者の`名前`（name:share で配分比率、複数指定可）")
+				fs.Var(&labels, "label", "`ラベル`（複数指定可）")
+				return func(args []string) error {
+					nt := NewTask{Title: args[0], ParentID: *parent, EpicID: *epic, Description: *desc, Assignees: assignees, Labels: labels}
+					weightArg := *weight
+					switch len(args) {
+					case 3:
+						if flagGiven(fs, "sprint") || flagGiven(fs, "weight") {
+							return usageErrorf("スプリント番号とウェイトは位置引数か --sprint / --weight のどちらかで指定してください")
+						}
+						n, err := parseCount("スプリント番号", args[1])
+						if err != nil {
+							return err
+						}
+						*sprint, weightArg = n, args[2]
+					case 2:
+						return usageErrorf("スプリント番号とウェイトは両方指定してください")
+					}
+					if *sprint < 0 {
+						return usageErrorf("スプリント番号は 0 以上で指定してください")
+					}
+					points, err := parseWeight(weightArg)
+					if err != nil {
+						return &usageError{msg: err.Error()}
+					}
+					nt.SprintNumber, nt.TaskWeight = *sprint, points
+					return AddTask(nt)
+				}
+			},
+		},
+		{
+			Name: "list", Summary: "タスクを一覧表示します",
+			Setup: func(fs *flag.FlagSet) func([]string) error {
+				label := fs.String("label", "", "`ラベル`が付いたタスクだけを表示")
+				return func([]string) error {
+					return ListTasks(*label)
+				}
+			},
+		},
+		{
+			Name: "board", CLIOnly: true,
+			Summary: "かんばんボードを全画面で表示し、カードを移動・編集します",
+			Setup: func(fs *flag.FlagSet) func([]string) error {
+				sprint := fs.Int("sprint", 0, "表示するスプリントの`番号`（省略時はすべてのタスク）")
+				label := fs.String("label", "", "`ラベル`が付いたタスクだけを表示")
+				return func([]string) error {
+					if *sprint < 0 {
+						return usageErrorf("スプリント番号は 0 以上で指定してください")
+					}
+					return ShowBoard(*sprint, *label)
+				}
+			},
+		},
+		{
+			Name: "show", Args: "<taskID>", MinArgs: 1, MaxArgs: 1,
+			Summary: "タスクの詳細（説明・受け入れ条件・コメント）を表示します",
+			Setup:   taskCommand(ShowTask),
+		},
+		{
+			Name: "assign", Args: "<taskID> [+name|-name|name:share ...]", MinArgs: 1, MaxArgs: anyArgs, RawArgs: true,
+			Summary: "担当者を追加・削除します（名前を省略すると全員外します）",
+			Setup: func(fs *flag.FlagSet) func([]string) error {
+				return func(args []string) error {
+					id, err := parseID("タスクID", args[0])
+					if err != nil {
+						return err
+					}
+					return AssignTask(id, args[1:])
+				}
+			},
+		},
+		{
+			Name: "complete", Args: "<taskID>", MinArgs: 1, MaxArgs: 1,
+			Summary: "タスクを完了にします",
+			Setup:   taskCommand(CompleteTask),
+		},
+		{
+			Name: "move", Args: "<taskID> <status>", MinArgs: 2, MaxArgs: 2,
+			Summary: "タスクのステータスを変更します",
+			Setup: func(fs *flag.FlagSet) func([]string) error {
+				return func(args []string) error {
+					id, err := parseID("タスクID", args[0])
+					if err != nil {
+						return err
+					}
+					return MoveTask(id, args[1])
+				}
+			},
+		},
+		{
+			Name: "delete", Args: "<taskID>", MinArgs: 1, MaxArgs: 1,
+			Summary: "タスクを削除します",
+			Setup:   taskCommand(DeleteTask),
+		},
+		{
+			Name: "estimate", Args: "<taskID> <value>", MinArgs: 2, MaxArgs: 2,
+			Summary: "タスクの見積もり（ウェイト）を更新します",
+			Setup: func(fs *flag.FlagSet) func([]string) error {
+				return func(args []string) error {
+					id, err := parseID("タスクID", args[0])
+					if err != nil {
+						return err
+					}
+					points, err := parseWeight(args[1]) // 0 で未見積もりに戻す
+					if err != nil {
+						return &usageError{msg: err.Error()}
+					}
+					return EstimateTask(id, points)
+				}
+			},
+		},
+		{
+			Name: "poker", Args: "<taskID> <name> [<name> ...]", MinArgs: 2, MaxArgs: anyArgs, Interactive: true,
+			Summary: "プランニングポーカーで見積もります",
+			Setup: func(fs *flag.FlagSet) func([]string) error {
+				return func(args []string) error {
+					id, err := parseID("タスクID", args[0])
+					if err != nil {
+						return err
+					}
+					return runPokerConsole(stdin, id, args[1:])
+				}
+			},
+		},
+		{
+			Name: "describe", Args: "<taskID> <description>", MinArgs: 2, MaxArgs: 2,
+			Summary: "タスクの説明を設定します（空文字で削除）",
+			Setup: func(fs *flag.FlagSet) func([]string) error {
+				return func(args []string) error {
+					id, err := parseID("タスクID", args[0])
+					if err != nil {
+						return err
+					}
+					return DescribeTask(id, args[1])
+				}
+			},
+		},
+		{
+			Name: "rename", Args: "<taskID> <title>", MinArgs: 2, MaxArgs: 2,
+			Summary: "タスクのタイトルを変更します",
+			Setup: func(fs *flag.FlagSet) func([]string) error {
+				return func(args []string) error {
+					id, err := parseID("タスクID", args[0])
+					if err != nil {
+						return err
+					}
+					return RenameTask(id, args[1])
+				}
+			},
+		},
+		{
+			Name: "edit", Args: "<taskID>", MinArgs: 1, MaxArgs: 1,
+			Summary: "タイトル・ウェイト・説明をまとめて変更します（指定した項目だけを変更）",
+			Setup: func(fs *flag.FlagSet) func([]string) error {
+				title := fs.String("title", "", "新しい`タイトル`")
+				weight := fs.String("weight", "", "タスクウェイト（見積もりスケールの`値`、0 は未見積もり）")
+				desc := fs.String("desc", "", "タスクの`説明`（空文字で削除）")
+				return func(args []string) error {
+					id, err := parseID("タスクID", args[0])
+					if err != nil {
+						return err
+					}
+					var edit TaskEdit
+					if flagGiven(fs, "title") {
+						edit.Title = title
+					}
+					if flagGiven(fs, "weight") {
+						points, err := parseWeight(*weight)
+						if err != nil {
+							return &usageError{msg: err.Error()}
+						}
+						edit.Weight = &points
+					}
+					if flagGiven(fs, "desc") {
+						edit.Description = desc
+					}
+					return EditTask(id, edit)
+				}
+			},
+		},
+		{
+			Name: "criteria", Args: "<taskID> add <text> | <taskID> check|uncheck|remove <number>", MinArgs: 3, MaxArgs: anyArgs,
+			Summary: "受け入れ条件を追加・チェック・削除します",
+			Setup: func(fs *flag.FlagSet) func([]string) error {
+				return func(args []string) error {
+					id, err := parseID("タスクID", args[0])
+					if err != nil {
+						return err
+					}
+					if args[1] == "add" {
+						return AddCriterion(id, strings.Join(args[2:], " "))
+					}
+					n, err := parseID("受け入れ条件の番号", args[2])
+					if err != nil {
+						return err
+					}
+					switch args[1] {
+					case "check":
+						return CheckCriterion(id, n, true)
+					case "uncheck":
+						return CheckCriterion(id, n, false)
+					case "remove":
+						return RemoveCriterion(id, n)
+					}
+					return usageErrorf("不明な操作です: %s（add / check / uncheck / remove）", args[1])
+				}
+			},
+		},
+		{
+			Name: "comment", Args: "<taskID> <text>", MinArgs: 2, MaxArgs: 2,
+			Summary: "タスクにコメントします",
+			Setup: func(fs *flag.FlagSet) func([]string) error {
+				reply := fs.Int("reply", 0, "返信先のコメント`ID`")
+				return func(args []string) error {
+					id, err := parseID("タスクID", args[0])
+					if err != nil {
+						return err
+					}
+					if *reply < 0 {
+						return usageErrorf("コメントIDは正の整数で指定してください")
+					}
+					return CommentTask(id, args[1], *reply)
+				}
+			},
+		},
+		{
+			Name: "label", Args: "<taskID> [+label|-label ...]", MinArgs: 2, MaxArgs: anyArgs, RawArgs: true,
+			Summary: "ラベルを追加・削除します",
+			Setup: func(fs *flag.FlagSet) func([]string) error {
+				return func(args []string) error {
+					id, err := parseID("タスクID", args[0])
+					if err != nil {
+						return err
+					}
+					return LabelTask(id, args[1:])
+				}
+			},
+		},
+		{
+			Name: "depend", Args: "<taskID> [-]<onTaskID>", MinArgs: 2, MaxArgs: 2, RawArgs: true,
+			Summary: "タスクが別のタスクの完了を待つ依存関係を追加します（-<onTaskID> で削除）",
+			Setup: func(fs *flag.FlagSet) func([]string) error {
+				return func(args []string) error {
+					id, err := parseID("タスクID", args[0])
+					if err != nil {
+						return err
+					}
+					onID, err := parseID("タスクID", strings.TrimPrefix(args[1], "-"))
+					if err != nil {
+						return err
+					}
+					return DependTask(id, onID, strings.HasPrefix(args[1], "-"))
+				}
+			},
+		},
+		{
+			Name: "graph", Summary: "依存関係を Graphviz の DOT 形式で出力します",
+			Setup: func(fs *flag.FlagSet) func([]string) error {
+				out := fs.String("out", "", "出力する`ファイル`（省略時は標準出力）")
+				return func([]string) error {
+					return ExportGraph(*out)
+				}
+			},
+		},
+		{
+			Name: "undo", Summary: "直前の操作を取り消します",
+			Setup: func(fs *flag.FlagSet) func([]string) error {
+				return func([]string) error { return Undo() }
+			},
+		},
+		{
+			Name: "redo", Summary: "取り消した操作をやり直します",
+			Setup: func(fs *flag.FlagSet) func([]string) error {
+				return func([]string) error { return Redo() }
+			},
+		},
+		{
+			Name: "backlog", Summary: "プロダクトバックログを優先順位順に表示します",
+			Setup: func(fs *flag.FlagSet) func([]string) error {
+				return func([]string) error {
+					return ShowBacklog()
+				}
+			},
+		},
+		{
+			Name: "rank", Args: "<taskID> <position> | <taskID> above|below <taskID>", MinArgs: 2, MaxArgs: 3,
+			Summary: "バックログの優先順位を変更します",
+			Setup: func(fs *flag.FlagSet) func([]string) error {
+				return func(args []string) error {
+					id, err := parseID("タスクID", args[0])
+					if err != nil {
+						return err
+					}
+					if args[1] == "above" || args[1] == "below" {
+						if len(args) < 3 {
+							return usageErrorf("比較するタスクIDを指定してください")
+						}
+						other, err := parseID("タスクID", args[2])
+						if err != nil {
+							return err
+						}
+						return RankTaskRelative(id, other, args[1] == "above")
+					}
+					if len(args) > 2 {
+						return usageErrorf("引数の数が正しくありません")
+					}
+					position, err := parseID("順位", args[1])
+					if err != nil {
+						return err
+					}
+					return RankTask(id, position)
+				}
+			},
+		},
+		{
+			Name: "plan", Args: "<sprintNumber>", MinArgs: 1, MaxArgs: 1,
+			Summary: "バックログの上位からキャパシティに収まるタスクをスプリントへ移します",
+			Setup: func(fs *flag.FlagSet) func([]string) error {
+				capacity := fs.Int("capacity", 0, "キャパシティの`ウェイト`（省略時はスプリントの設定か直近の実績）")
+				return func(args []string) error {
+					number, err := parseID("スプリント番号", args[0])
+					if err != nil {
+						return err
+					}
+					if *capacity < 0 {
+						return usageErrorf("キャパシティは正の数値で指定してください")
+					}
+					return PlanSprint(number, *capacity)
+				}
+			},
+		},
+		{
+			Name: "capacity", Args: "<sprintNumber>", MinArgs: 1, MaxArgs: 1,
+			Summary: "スプリントの担当者ごとの負荷とキャパシティを表示します",
+			Setup:   sprintCommand(ShowCapacity),
+		},
+		{
+			Name: "sprint", Summary: "スプリントを作成・開始・終了・表示します",
+			Sub: []*command{
+				{
+					Name: "create", Args: "<number>", MinArgs: 1, MaxArgs: 1,
+					Summary: "スプリントを作成します",
+					Setup: func(fs *flag.FlagSet) func([]string) error {
+						var start, end dateFlag
+						capacity := capacityFlag{}
+						name := fs.String("name", "", "スプリントの`名前`")
+						goal := fs.String("goal", "", "スプリント`ゴール`")
+						fs.Var(&start, "start", "開始日（`YYYY-MM-DD`）")
+						fs.Var(&end, "end", "終了日（`YYYY-MM-DD`）")
+						fs.Var(capacity, "capacity", "担当者のキャパシティ（`名前=ウェイト`、複数指定可）")
+						return func(args []string) error {
+							number, err := parseID("スプリント番号", args[0])
+							if err != nil {
+								return err
+							}
+							return CreateSprint(Sprint{Number: number, Name: *name, Goal: *goal,
+								StartDate: string(start), EndDate: string(end), Capacity: capacity})
+						}
+					},
+				},
+				{
+					Name: "start", Args: "<number>", MinArgs: 1, MaxArgs: 1,
+					Summary: "スプリントを開始します",
+					Setup:   sprintCommand(StartSprint),
+				},
+				{
+					Name: "close", Args: "<number>", MinArgs: 1, MaxArgs: 1, Interactive: true, AnswerFlags: []string{"unfinished"},
+					Summary: "スプリントを終了し、未完了タスクを持ち越し・バックログへ戻し・削除します",
+					Setup: func(fs *flag.FlagSet) func([]string) error {
+						mode := fs.String("unfinished", "", "未完了タスクの`扱い`（carry / backlog / drop、省略時は1件ずつ確認）")
+						return func(args []string) error {
+							number, err := parseID("スプリント番号", args[0])
+							if err != nil {
+								return err
+							}
+							return CloseSprint(number, *mode)
+						}
+					},
+				},
+				{
+					Name: "show", Args: "<number>", MinArgs: 1, MaxArgs: 1,
+					Summary: "スプリントの詳細を表示します",
+					Setup:   sprintCommand(ShowSprint),
+				},
+				{
+					Name: "list", Summary: "スプリントの一覧を表示します",
+					Setup: func(fs *flag.FlagSet) func([]string) error {
+						return func([]string) error {
+							return ListSprints()
+						}
+					},
+				},
+			},
+		},
+		{
+			Name: "epic", Summary: "エピックを作成・表示し、タスクを紐づけます",
+			Sub: []*command{
+				{
+					Name: "create", Args: "<title>", MinArgs: 1, MaxArgs: 1,
+					Summary: "エピックを作成します",
+					Setup: func(fs *flag.FlagSet) func([]string) error {
+						desc := fs.String("desc", "", "エピックの`説明`")
+						return func(args []string) error {
+							return CreateEpic(args[0], *desc)
+						}
+					},
+				},
+				{
+					Name: "list", Summary: "エピックの一覧と完了率を表示します",
+					Setup: func(fs *flag.FlagSet) func([]string) error {
+						return func([]string) error {
+							return ListEpics()
+						}
+					},
+				},
+				{
+					Name: "show", Args: "<epicID>", MinArgs: 1, MaxArgs: 1,
+					Summary: "エピックの詳細と属するタスクを表示します",
+					Setup: func(fs *flag.FlagSet) func([]string) error {
+						return func(args []string) error {
+							id, err := parseID("エピックID", args[0])
+							if err != nil {
+								return err
+							}
+							return ShowEpic(id)
+						}
+					},
+				},
+				{
+					Name: "link", Args: "<epicID|0> <taskID>", MinArgs: 2, MaxArgs: 2,
+					Summary: "タスクをエピックに紐づけます（0 で紐づけを外す）",
+					Setup: func(fs *flag.FlagSet) func([]string) error {
+						return func(args []string) error {
+							epicID, err := parseCount("エピックID", args[0])
+							if err != nil {
+								return err
+							}
+							taskID, err := parseID("タスクID", args[1])
+							if err != nil {
+								return err
+							}
+							return LinkEpic(epicID, taskID)
+						}
+					},
+				},
+			},
+		},
+		{
+			Name: "timerstart", CLIOnly: true,
+			Summary: "スプリントタイマーを開始します",
+			Setup: func(fs *flag.FlagSet) func([]string) error {
+				label := fs.String("label", "", "表示するタスクの`ラベル`")
+				tui := fs.Bool("tui", false, "全画面（進捗バーとタスクのペイン）で表示する")
+				return func([]string) error {
+					if *tui {
+						return TimerStartSprintTUI(*label)
+					}
+					return TimerStartSprint(*label)
+				}
+			},
+		},
+		{
+			Name: "pause", TimerOnly: true,
+			Summary: "スプリントタイマーを一時停止します",
+			Setup: func(fs *flag.FlagSet) func([]string) error {
+				return func([]string) error { return PauseTimer() }
+			},
+		},
+		{
+			Name: "resume", TimerOnly: true,
+			Summary: "一時停止したスプリントタイマーを再開します",
+			Setup: func(fs *flag.FlagSet) func([]string) error {
+				return func([]string) error { return ResumeTimer() }
+			},
+		},
+		{
+			Name: "extend", Args: "<minutes>", MinArgs: 1, MaxArgs: 1, TimerOnly: true,
+			Summary: "現在のフェーズを延長します",
+			Setup: func(fs *flag.FlagSet) func([]string) error {
+				return func(args []string) error {
+					minutes, err := parseID("延長する分数", args[0])
+					if err != nil {
+						return err
+					}
+					return ExtendTimer(minutes)
+				}
+			},
+		},
+		{
+			Name: "skip", TimerOnly: true,
+			Summary: "現在のフェーズを終了し、次のフェーズへ進めます",
+			Setup: func(fs *flag.FlagSet) func([]string) error {
+				return func([]string) error { return SkipPhase() }
+			},
+		},
+		{
+			Name: "status", TimerOnly: true,
+			Summary: "スプリントタイマーの残り時間と各フェーズの予定・実績を表示します",
+			Setup: func(fs *flag.FlagSet) func([]string) error {
+				return func([]string) error { return ShowTimerStatus() }
+			},
+		},
+		{
+			Name: "timersetting", Args: "<planningTime> <developmentTime> <reviewTime>", MinArgs: 3, MaxArgs: 3,
+			Summary: "スプリントタイマーの各フェーズの時間（分）を設定します",
+			Setup: func(fs *flag.FlagSet) func([]string) error {
+				return func(args []string) error {
+					minutes := make([]int, 3)
+					for i, what := range []string{"スプリント計画の時間", "開発の時間", "レビューの時間"} {
+						n, err := parseID(what, args[i])
+						if err != nil {
+							return err
+						}
+						minutes[i] = n
+					}
+					return TimerSetting(minutes[0], minutes[1], minutes[2])
+				}
+			},
+		},
+		{
+			Name: "progress", Summary: "担当者・エピック・ラベルごとの進捗を表示し、グラフを出力します",
+			Setup: func(fs *flag.FlagSet) func([]string) error {
+				label := fs.String("label", "", "`ラベル`が付いたタスクだけを集計")
+				return func([]string) error {
+					return ShowProgress(*label)
+				}
+			},
+		},
+		{
+			Name: "contribution", Summary: "担当者ごとの貢献度の円グラフを出力します",
+			Setup: func(fs *flag.FlagSet) func([]string) error {
+				label := fs.String("label", "", "`ラベル`が付いたタスクだけを集計")
+				return func([]string) error {
+					return ShowContribution(*label)
+				}
+			},
+		},
+		{
+			Name: "burndown", Args: "[sprintNumber]", MaxArgs: 1,
+			Summary: "バーンダウンチャートを出力します（省略時は現在のスプリント）",
+			Setup: func(fs *flag.FlagSet) func([]string) error {
+				out := fs.String("out", "burndown.png", "出力する`ファイル`（.png / .svg）")
+				return func(args []string) error {
+					sprint := 0
+					if len(args) == 1 {
+						n, err := parseID("スプリント番号", args[0])
+						if err != nil {
+							return err
+						}
+						sprint = n
+					}
+					return ShowBurndown(sprint, *out)
+				}
+			},
+		},
+		{
+			Name: "velocity", Summary: "スプリントごとのベロシティを表示し、グラフを出力します",
+			Setup: func(fs *flag.FlagSet) func([]string) error {
+				window := fs.Int("window", 3, "移動平均の`スプリント数`")
+				return func([]string) error {
+					if *window < 1 {
+						return usageErrorf("--window は 1 以上で指定してください")
+					}
+					return ShowVelocity(*window)
+				}
+			},
+		},
+		{
+			Name: "cycletime", Args: "[sprintNumber]", MaxArgs: 1,
+			Summary: "リードタイムとサイクルタイムを表示します",
+			Setup: func(fs *flag.FlagSet) func([]string) error {
+				return func(args []string) error {
+					sprint := 0
+					if len(args) == 1 {
+						n, err := parseID("スプリント番号", args[0])
+						if err != nil {
+							return err
+						}
+						sprint = n
+					}
+					return ShowCycleTime(sprint)
+				}
+			},
+		},
+		{
+			Name: "history", Args: "<taskID>", MinArgs: 1, MaxArgs: 1,
+			Summary: "タスクの変更履歴を表示します",
+			Setup:   taskCommand(ShowHistory),
+		},
+		{
+			Name: "log", Summary: "変更履歴を条件で絞り込んで表示します",
+			Setup: func(fs *flag.FlagSet) func([]string) error {
+				var since, until dateFlag
+				sprint := fs.Int("sprint", 0, "スプリント`番号`")
+				person := fs.String("person", "", "操作した人の`名前`")
+				fs.Var(&since, "since", "この日以降（`YYYY-MM-DD`）")
+				fs.Var(&until, "until", "この日まで（`YYYY-MM-DD`）")
+				return func([]string) error {
+					filter := EventFilter{Sprint: *sprint, Person: *person}
+					if since != "" {
+						filter.Since, _ = time.ParseInLocation(dateLayout, string(since), time.Local)
+					}
+					if until != "" {
+						t, _ := time.ParseInLocation(dateLayout, string(until), time.Local)
+						filter.Until = t.AddDate(0, 0, 1) // 指定日を含める
+					}
+					return ShowLog(filter)
+				}
+			},
+		},
+		{
+			Name: "migrate", CLIOnly: true,
+			Summary: "旧形式のデータを現在の形式に変換します",
+			Setup: func(fs *flag.FlagSet) func([]string) error {
+				return func([]string) error {
+					return MigrateTasks()
+				}
+			},
+		},
+		{
+			Name: "doctor", CLIOnly: true,
+			Summary: "タスクデータを検査し、修復します",
+			Setup: func(fs *flag.FlagSet) func([]string) error {
+				fix := fs.Bool("fix", false, "問題を一括で修復する")
+				var interactive bool
+				fs.BoolVar(&interactive, "interactive", false, "問題ごとに修復方法を尋ねる")
+				fs.BoolVar(&interactive, "i", false, "--interactive の短縮形")
+				return func([]string) error {
+					return Doctor(*fix, interactive)
+				}
+			},
+		},
+	}
+}
+
+// taskCommand は <taskID> だけを受け取るコマンドの Setup を作ります。
+func taskCommand(run func(id int) error) func(*flag.FlagSet) func([]string) error {
+	return func(*flag.FlagSet) func([]string) error {
+		return func(args []string) error {
+			id, err := parseID("タスクID", args[0])
+			if err != nil {
+				return err
+			}
+			return run(id)
+		}
+	}
+}
+
+// sprintCommand は <sprintNumber> だけを受け取るコマンドの Setup を作ります。
+func sprintCommand(run func(number int) error) func(*flag.FlagSet) func([]string) error {
+	return func(*flag.FlagSet) func([]string) error {
+		return func(args []string) error {
+			number, err := parseID("スプリント番号", args[0])
+			if err != nil {
+				return err
+			}
+			return run(number)
+		}
+	}
+}
@@ -0,0 +1,168 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// ShowCycleTime はリードタイム（追加→完了）とサイクルタイム（着手→完了）を
+// タスクごと・スプリントごと・担当者ごとに表示します。sprint が 0 なら全スプリントが対象です。
+func ShowCycleTime(sprint int) error {
+	tasks, err := store.List()
+	if err != nil {
+		return err
+	}
+
+	type sample struct {
+		count       int
+		lead, cycle []time.Duration
+	}
+	bySprint := make(map[int]*sample)
+	byAssignee := make(map[string]*sample)
+	add := func(s *sample, lead, cycle *time.Duration) {
+		s.count++
+		if lead != nil {
+			s.lead = append(s.lead, *lead)
+		}
+		if cycle != nil {
+			s.cycle = append(s.cycle, *cycle)
+		}
+	}
+
+	// ==== タスクごと ======================================================
+	table := &Table{Title: "タスクごと", Header: []string{"ID", "Title", "Sprint", "Assignees", "Created", "Started", "Completed", "Lead", "Cycle"}}
+	for _, t := range tasks {
+		if sprint != 0 && t.SprintNumber != sprint {
+			continue
+		}
+		if !t.IsDone() || t.CompletedAt == nil {
+			continue // 未完了のタスクは対象外
+		}
+
+		var lead, cycle *time.Duration
+		if t.CreatedAt != nil {
+			d := t.CompletedAt.Sub(*t.CreatedAt)
+			lead = &d
+		}
+		if t.StartedAt != nil {
+			d := t.CompletedAt.Sub(*t.StartedAt)
+			cycle = &d
+		}
+
+		if _, ok := bySprint[t.SprintNumber]; !ok {
+			bySprint[t.SprintNumber] = &sample{}
+		}
+		add(bySprint[t.SprintNumber], lead, cycle)
+		for _, a := range t.Assignees {
+			if _, ok := byAssignee[a.Name]; !ok {
+				byAssignee[a.Name] = &sample{}
+			}
+			add(byAssignee[a.Name], lead, cycle)
+		}
+
+		table.Append([]string{
+			strconv.Itoa(t.ID),
+			t.Title,
+			strconv.Itoa(t.SprintNumber),
+			t.Assignees.String(),
+			formatTime(t.CreatedAt),
+			formatTime(t.StartedAt),
+			formatTime(t.CompletedAt),
+			formatDurationPtr(lead),
+			formatDurationPtr(cycle),
+		})
+	}
+	output.AddTable(table)
+
+	// ==== 集計表示 ========================================================
+	header := func(first string) []string {
+		return []string{first, "Count", "Lead p50", "Lead p85", "Lead p95", "Cycle p50", "Cycle p85", "Cycle p95"}
+	}
+	summaryRow := func(label string, s *sample) []string {
+		return []string{
+			label,
+			strconv.Itoa(s.count),
+			formatPercentile(s.lead, 50),
+			formatPercentile(s.lead, 85),
+			formatPercentile(s.lead, 95),
+			formatPercentile(s.cycle, 50),
+			formatPercentile(s.cycle, 85),
+			formatPercentile(s.cycle, 95),
+		}
+	}
+
+	sprints := make([]int, 0, len(bySprint))
+	for n := range bySprint {
+		sprints = append(sprints, n)
+	}
+	sort.Ints(sprints)
+	table = &Table{Title: "スプリントごと", Header: header("Sprint")}
+	for _, n := range sprints {
+		table.Append(summaryRow(strconv.Itoa(n), bySprint[n]))
+	}
+	output.AddTable(table)
+
+	names := make([]string, 0, len(byAssignee))
+	for name := range byAssignee {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	table = &Table{Title: "担当者ごと", Header: header("Assignee")}
+	for _, name := range names {
+		table.Append(summaryRow(name, byAssignee[name]))
+	}
+	output.AddTable(table)
+	return nil
+}
+
+// percentile は最近傍順位法でパーセンタイル値を求めます。ds が空なら 0 を返します。
+func percentile(ds []time.Duration, p float64) time.Duration {
+	if len(ds) == 0 {
+		return 0
+	}
+	sorted := append([]time.Duration{}, ds...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	if rank < 1 {
+		rank = 1
+	}
+	if rank > len(sorted) {
+		rank = len(sorted)
+	}
+	return sorted[rank-1]
+}
+
+func formatPercentile(ds []time.Duration, p float64) string {
+	if len(ds) == 0 {
+		return "-"
+	}
+	return formatDuration(percentile(ds, p))
+}
+
+// formatDuration は期間を "2d03h" や "4h05m" の形式にします。
+func formatDuration(d time.Duration) string {
+	if d < 0 {
+		d = 0
+	}
+	if d >= 24*time.Hour {
+		return fmt.Sprintf("%dd%02dh", int(d/(24*time.Hour)), int(d%(24*time.Hour)/time.Hour))
+	}
+	return fmt.Sprintf("%dh%02dm", int(d/time.Hour), int(d%time.Hour/time.Minute))
+}
+
+func formatDurationPtr(d *time.Duration) string {
+	if d == nil {
+		return "-"
+	}
+	return formatDuration(*d)
+}
+
+func formatTime(t *time.Time) string {
+	if t == nil {
+		return "-"
+	}
+	return t.Local().Format("2006-01-02 15:04")
+}
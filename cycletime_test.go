@@ -0,0 +1,33 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPercentile(t *testing.T) {
+	h := time.Hour
+	tests := []struct {
+		name string
+		ds   []time.Duration
+		p    float64
+		want time.Duration
+	}{
+		{"空", nil, 50, 0},
+		{"1件（中央値）", []time.Duration{3 * h}, 50, 3 * h},
+		{"1件（100%）", []time.Duration{3 * h}, 100, 3 * h},
+		{"1件（0%）", []time.Duration{3 * h}, 0, 3 * h},
+		{"中央値（並べ替え）", []time.Duration{5 * h, 1 * h, 3 * h, 4 * h, 2 * h}, 50, 3 * h},
+		{"85%", []time.Duration{5 * h, 1 * h, 3 * h, 4 * h, 2 * h}, 85, 5 * h},
+		{"100% は最大値", []time.Duration{5 * h, 1 * h, 3 * h}, 100, 5 * h},
+		{"1% は最小値", []time.Duration{5 * h, 1 * h, 3 * h}, 1, 1 * h},
+	}
+	for _, tt := range tests {
+		if got := percentile(tt.ds, tt.p); got != tt.want {
+			t.Errorf("%s: percentile(%v, %v) = %v, want %v", tt.name, tt.ds, tt.p, got, tt.want)
+		}
+	}
+	if got := formatPercentile(nil, 50); got != "-" {
+		t.Errorf("formatPercentile(nil) = %q, want \"-\"", got)
+	}
+}
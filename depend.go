@@ -0,0 +1,227 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// 依存関係は Task.DependsOn に「先に完了している必要があるタスクの ID」として保存します。
+// 依存先がすべて完了するまで、そのタスクはブロック中として表示されます。
+
+// blockers は t の依存先のうち、まだ完了していないタスクの ID を返します。
+// 存在しない依存先は無視します（doctor で警告）。
+func blockers(t Task, byID map[int]Task) []int {
+	if t.IsDone() {
+		return nil
+	}
+	ids := []int{}
+	for _, dep := range t.DependsOn {
+		if d, ok := byID[dep]; ok && !d.IsDone() {
+			ids = append(ids, dep)
+		}
+	}
+	return ids
+}
+
+// formatIDs は ID の一覧を "#23, #24" の形式にします。
+func formatIDs(ids []int) string {
+	parts := make([]string, len(ids))
+	for i, id := range ids {
+		parts[i] = "#" + strconv.Itoa(id)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// statusWithBlockers はブロック中のタスクのステータスに待っているタスクを付け加えます。
+func statusWithBlockers(t Task, byID map[int]Task) string {
+	if ids := blockers(t, byID); len(ids) > 0 {
+		return fmt.Sprintf("%s (待ち: %s)", t.Status, formatIDs(ids))
+	}
+	return t.Status
+}
+
+func tasksByID(tasks []Task) map[int]Task {
+	byID := make(map[int]Task, len(tasks))
+	for _, t := range tasks {
+		byID[t.ID] = t
+	}
+	return byID
+}
+
+// dependsTransitively は from から依存関係をたどって to に到達できるかを返します。
+func dependsTransitively(from int, to int, byID map[int]Task) bool {
+	visited := make(map[int]bool)
+	stack := []int{from}
+	for len(stack) > 0 {
+		id := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if id == to {
+			return true
+		}
+		if visited[id] {
+			continue
+		}
+		visited[id] = true
+		stack = append(stack, byID[id].DependsOn...)
+	}
+	return false
+}
+
+// DependTask はタスク id が onID の完了を待つ依存関係を追加します。remove の場合は削除します。
+func DependTask(id int, onID int, remove bool) error {
+	unlock, err := store.Lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	tasks, err := store.List()
+	if err != nil {
+		return err
+	}
+	byID := tasksByID(tasks)
+	task, ok := byID[id]
+	if !ok {
+		return notFound(ErrTaskNotFound, "タスク #%d は存在しません", id)
+	}
+	before := task
+	oldDeps := formatIDs(task.DependsOn)
+
+	if remove {
+		deps := []int{}
+		for _, dep := range task.DependsOn {
+			if dep != onID {
+				deps = append(deps, dep)
+			}
+		}
+		if len(deps) == len(task.DependsOn) {
+			return fmt.Errorf("タスク #%d は #%d に依存していません", id, onID)
+		}
+		task.DependsOn = deps
+	} else {
+		if _, ok := byID[onID]; !ok {
+			return notFound(ErrTaskNotFound, "タスク #%d は存在しません", onID)
+		}
+		if id == onID {
+			return fmt.Errorf("タスク自身には依存できません")
+		}
+		for _, dep := range task.DependsOn {
+			if dep == onID {
+				return fmt.Errorf("タスク #%d は既に #%d に依存しています", id, onID)
+			}
+		}
+		if dependsTransitively(onID, id, byID) {
+			return fmt.Errorf("#%d は既に #%d の完了を待っているため、依存関係が循環します", onID, id)
+		}
+		task.DependsOn = append(append([]int{}, task.DependsOn...), onID)
+	}
+
+	if err := store.Update(task); err != nil {
+		return err
+	}
+	recordEvent(task, "depend", "depends_on", oldDeps, formatIDs(task.DependsOn))
+	recordTaskOp(fmt.Sprintf("depend #%d", id), id, &before, &task)
+	if remove {
+		output.Printf("タスク #%d の #%d への依存を削除しました", id, onID)
+	} else {
+		output.Printf("タスク #%d は #%d の完了を待ちます", id, onID)
+	}
+	return nil
+}
+
+// reportUnblocked はタスク id の完了によってブロックが解除されたタスクを表示します。
+func reportUnblocked(id int) error {
+	tasks, err := store.List()
+	if err != nil {
+		return err
+	}
+	byID := tasksByID(tasks)
+	for _, t := range tasks {
+		if t.IsDone() || len(blockers(t, byID)) > 0 {
+			continue
+		}
+		for _, dep := range t.DependsOn {
+			if dep == id {
+				output.Printf("#%d %s が着手可能になりました", t.ID, t.Title)
+			}
+		}
+	}
+	return nil
+}
+
+// ExportGraph は依存関係を Graphviz の DOT 形式で出力します。out が空の場合はコマンドの出力として表示します。
+func ExportGraph(out string) error {
+	tasks, err := store.List()
+	if err != nil {
+		return err
+	}
+
+	if out == "" {
+		var buf strings.Builder
+		if err := writeDOT(&buf, tasks); err != nil {
+			return err
+		}
+		output.Printf("%s", strings.TrimSuffix(buf.String(), "\n"))
+		return nil
+	}
+	file, err := os.Create(out)
+	if err != nil {
+		return fmt.Errorf("ファイルを作成できませんでした: %w", err)
+	}
+	if err := writeDOT(file, tasks); err != nil {
+		file.Close()
+		return fmt.Errorf("依存関係グラフを書き込めませんでした: %w", err)
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("依存関係グラフを書き込めませんでした: %w", err)
+	}
+	output.Printf("依存関係グラフ(%s)を出力しました。dot -Tpng %s -o graph.png で画像にできます。", out, out)
+	return nil
+}
+
+// writeDOT は依存関係のあるタスクだけをノードにしたグラフを書き出します。
+// 辺は「先に完了するタスク → 待っているタスク」の向きです。
+func writeDOT(out io.Writer, tasks []Task) error {
+	w := bufio.NewWriter(out) // 書き込みのエラーは Flush でまとめて受け取る
+	byID := tasksByID(tasks)
+	linked := make(map[int]bool)
+	for _, t := range tasks {
+		for _, dep := range t.DependsOn {
+			if _, ok := byID[dep]; ok {
+				linked[t.ID], linked[dep] = true, true
+			}
+		}
+	}
+
+	fmt.Fprintln(w, "digraph dependencies {")
+	fmt.Fprintln(w, "  rankdir=LR;")
+	fmt.Fprintln(w, "  node [shape=box, style=filled, fillcolor=white];")
+	for _, t := range tasks {
+		if !linked[t.ID] {
+			continue
+		}
+		fill := "white"
+		switch {
+		case t.IsDone():
+			fill = "lightgray"
+		case len(blockers(t, byID)) > 0:
+			fill = "mistyrose"
+		case t.Status == workflow.Start:
+			fill = "lightyellow"
+		}
+		fmt.Fprintf(w, "  t%d [label=%s, fillcolor=%s];\n", t.ID, strconv.Quote(fmt.Sprintf("#%d %s\n%s", t.ID, t.Title, t.Status)), fill)
+	}
+	for _, t := range tasks {
+		for _, dep := range t.DependsOn {
+			if _, ok := byID[dep]; ok {
+				fmt.Fprintf(w, "  t%d -> t%d;\n", dep, t.ID)
+			}
+		}
+	}
+	fmt.Fprintln(w, "}")
+	return w.Flush()
+}
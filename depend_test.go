@@ -0,0 +1,159 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDependsTransitively(t *testing.T) {
+	byID := tasksByID([]Task{
+		{ID: 1, DependsOn: []int{2}},
+		{ID: 2, DependsOn: []int{3}},
+		{ID: 3},
+		{ID: 4, DependsOn: []int{4, 1}}, // 壊れたデータで自己参照があっても止まる
+	})
+	tests := []struct {
+		from, to int
+		want     bool
+	}{
+		{1, 2, true},
+		{1, 3, true},
+		{4, 3, true},
+		{3, 1, false},
+		{2, 1, false},
+		{1, 99, false},
+	}
+	for _, tt := range tests {
+		if got := dependsTransitively(tt.from, tt.to, byID); got != tt.want {
+			t.Errorf("dependsTransitively(%d, %d) = %v, want %v", tt.from, tt.to, got, tt.want)
+		}
+	}
+}
+
+func TestDependRejectsCycles(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+	}{
+		{"自分自身", []string{"1", "1"}},
+		{"直接の循環", []string{"2", "1"}},
+		{"間接の循環", []string{"3", "1"}},
+		{"重複", []string{"1", "2"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setupStore(t,
+				Task{ID: 1, Title: "a", Status: "todo", TaskWeight: 1, DependsOn: []int{2}},
+				Task{ID: 2, Title: "b", Status: "todo", TaskWeight: 1, DependsOn: []int{3}},
+				Task{ID: 3, Title: "c", Status: "todo", TaskWeight: 1},
+			)
+			if res := run(t, append([]string{"depend"}, tt.args...)...); res.Err == nil {
+				t.Errorf("depend %v がエラーになりません", tt.args)
+			}
+			if deps := getTask(t, 3).DependsOn; len(deps) != 0 {
+				t.Errorf("#3 の依存先 = %v, want なし", deps)
+			}
+		})
+	}
+}
+
+func TestDependAddRemove(t *testing.T) {
+	setupStore(t,
+		Task{ID: 1, Title: "a", Status: "todo", TaskWeight: 1},
+		Task{ID: 2, Title: "b", Status: "todo", TaskWeight: 1},
+		Task{ID: 3, Title: "c", Status: "todo", TaskWeight: 1},
+	)
+	mustRun(t, "depend", "1", "2")
+	mustRun(t, "depend", "1", "3")
+	if got := getTask(t, 1).DependsOn; !reflect.DeepEqual(got, []int{2, 3}) {
+		t.Errorf("#1 の依存先 = %v, want [2 3]", got)
+	}
+	mustRun(t, "depend", "1", "-2")
+	if got := getTask(t, 1).DependsOn; !reflect.DeepEqual(got, []int{3}) {
+		t.Errorf("#1 の依存先 = %v, want [3]", got)
+	}
+	if res := run(t, "depend", "1", "-2"); res.Err == nil {
+		t.Error("依存していないタスクの削除がエラーになりません")
+	}
+	// 依存を外した後なら逆向きの依存を追加できる
+	mustRun(t, "depend", "2", "1")
+}
+
+func TestCompleteReportsUnblocked(t *testing.T) {
+	setupStore(t,
+		Task{ID: 1, Title: "a", Status: "todo", TaskWeight: 1},
+		Task{ID: 2, Title: "b", Status: "todo", TaskWeight: 1},
+		Task{ID: 3, Title: "waits a", Status: "todo", TaskWeight: 1, DependsOn: []int{1}},
+		Task{ID: 4, Title: "waits a and b", Status: "todo", TaskWeight: 1, DependsOn: []int{1, 2}},
+	)
+	if got := statusWithBlockers(getTask(t, 4), tasksByID(loadTasks(t))); got != "todo (待ち: #1, #2)" {
+		t.Errorf("statusWithBlockers() = %q", got)
+	}
+	out := mustRun(t, "complete", "1").String()
+	if !strings.Contains(out, "#3 waits a が着手可能になりました") {
+		t.Errorf("#3 のブロック解除が表示されません:\n%s", out)
+	}
+	if strings.Contains(out, "#4") {
+		t.Errorf("#2 を待っている #4 が着手可能と表示されました:\n%s", out)
+	}
+}
+
+func TestWriteDOT(t *testing.T) {
+	tasks := []Task{
+		{ID: 1, Title: "設計", Status: "done"},
+		{ID: 2, Title: "実装", Status: "doing", DependsOn: []int{1}},
+		{ID: 3, Title: "テスト", Status: "todo", DependsOn: []int{2, 9}}, // 存在しない依存先は辺にしない
+		{ID: 4, Title: "リリース \"v1\"", Status: "todo", DependsOn: []int{1}},
+		{ID: 5, Title: "依存なし", Status: "todo"},
+	}
+	want := `digraph dependencies {
+  rankdir=LR;
+  node [shape=box, style=filled, fillcolor=white];
+  t1 [label="#1 設計\ndone", fillcolor=lightgray];
+  t2 [label="#2 実装\ndoing", fillcolor=lightyellow];
+  t3 [label="#3 テスト\ntodo", fillcolor=mistyrose];
+  t4 [label="#4 リリース \"v1\"\ntodo", fillcolor=white];
+  t1 -> t2;
+  t2 -> t3;
+  t1 -> t4;
+}
+`
+	var buf strings.Builder
+	if err := writeDOT(&buf, tasks); err != nil {
+		t.Fatal(err)
+	}
+	if got := buf.String(); got != want {
+		t.Errorf("writeDOT =\n%s\nwant\n%s", got, want)
+	}
+
+	if err := writeDOT(failingWriter{}, tasks); !errors.Is(err, errDisk) {
+		t.Errorf("書き込みに失敗したときの err = %v, want %v", err, errDisk)
+	}
+}
+
+// failingWriter は常に書き込みに失敗します。
+type failingWriter struct{}
+
+func (failingWriter) Write([]byte) (int, error) { return 0, errDisk }
+
+func TestExportGraphToFile(t *testing.T) {
+	setupStore(t,
+		Task{ID: 1, Title: "a", Status: "done"},
+		Task{ID: 2, Title: "b", Status: "todo", DependsOn: []int{1}},
+	)
+	mustRun(t, "graph", "--out", "deps.dot")
+	data, err := os.ReadFile("deps.dot")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), "t1 -> t2;") {
+		t.Errorf("deps.dot に辺がありません:\n%s", data)
+	}
+	if res := run(t, "graph", "--out", filepath.Join("missing", "deps.dot")); res.Err == nil {
+		t.Error("作成できないファイルへの出力が成功しました")
+	}
+}
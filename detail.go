@@ -0,0 +1,351 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Criterion は受け入れ条件のチェック項目です。
+type Criterion struct {
+	Text string `json:"text"`
+	Done bool   `json:"done,omitempty"`
+}
+
+// Comment はタスクへのコメントです。ReplyTo が 0 でなければそのコメントへの返信です。
+type Comment struct {
+	ID      int       `json:"id"`
+	Author  string    `json:"author"`
+	Time    time.Time `json:"time"`
+	Text    string    `json:"text"`
+	ReplyTo int       `json:"reply_to,omitempty"`
+}
+
+// uncheckedCriteria は未チェックの受け入れ条件を返します。
+func (t Task) uncheckedCriteria() []Criterion {
+	unchecked := []Criterion{}
+	for _, c := range t.Criteria {
+		if !c.Done {
+			unchecked = append(unchecked, c)
+		}
+	}
+	return unchecked
+}
+
+// warnUncheckedCriteria は未チェックの受け入れ条件があれば警告を表示します。
+func warnUncheckedCriteria(t Task) {
+	unchecked := t.uncheckedCriteria()
+	if len(unchecked) == 0 {
+		return
+	}
+	output.Warnf("#%d の受け入れ条件が %d 件未チェックです", t.ID, len(unchecked))
+	for _, c := range unchecked {
+		output.Println("  " + criterionLine(c))
+	}
+}
+
+// updateTask はタスクを読み込んで change を適用し、保存・履歴・取り消し用の記録を行います。
+// change がエラーを返した場合は何も保存せずにそのエラーを返します。
+func updateTask(id int, desc string, change func(t *Task) (field, oldValue, newValue string, err error)) error {
+	unlock, err := store.Lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	task, err := findTask(id)
+	if err != nil {
+		return err
+	}
+	before := task
+	field, oldValue, newValue, err := change(&task)
+	if err != nil {
+		return err
+	}
+	if err := store.Update(task); err != nil {
+		return err
+	}
+	recordEvent(task, desc, field, oldValue, newValue)
+	recordTaskOp(fmt.Sprintf("%s #%d", desc, id), id, &before, &task)
+	return nil
+}
+
+// DescribeTask はタスクの説明を設定します。空文字を指定すると説明を消します。
+func DescribeTask(id int, description string) error {
+	if err := updateTask(id, "describe", func(t *Task) (string, string, string, error) {
+		old := t.Description
+		t.Description = description
+		return "description", old, description, nil
+	}); err != nil {
+		return err
+	}
+	output.Printf("タスク #%d の説明を更新しました", id)
+	return nil
+}
+
+// RenameTask はタスクのタイトルを変更します。
+func RenameTask(id int, title string) error {
+	if strings.TrimSpace(title) == "" {
+		return fmt.Errorf("タイトルを指定してください")
+	}
+	if err := checkText("タイトル", title); err != nil {
+		return err
+	}
+	if err := updateTask(id, "rename", func(t *Task) (string, string, string, error) {
+		old := t.Title
+		t.Title = title
+		return "title", old, title, nil
+	}); err != nil {
+		return err
+	}
+	output.Printf("タスク #%d のタイトルを「%s」に変更しました", id, title)
+	return nil
+}
+
+// TaskEdit は edit で変更する項目です。nil の項目は変更しません。
+type TaskEdit struct {
+	Title       *string
+	Weight      *int
+	Description *string
+}
+
+// EditTask はタイトル・ウェイト・説明をまとめて変更します。
+// 1つの操作として保存するため、undo も1回で全項目が元に戻ります。
+func EditTask(id int, edit TaskEdit) error {
+	if edit.Title != nil {
+		if strings.TrimSpace(*edit.Title) == "" {
+			return fmt.Errorf("タイトルを指定してください")
+		}
+		if err := checkText("タイトル", *edit.Title); err != nil {
+			return err
+		}
+	}
+
+	unlock, err := store.Lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	task, err := findTask(id)
+	if err != nil {
+		return err
+	}
+	before := task
+	type change struct{ field, oldValue, newValue string }
+	changes := []change{}
+	if edit.Title != nil && *edit.Title != task.Title {
+		changes = append(changes, change{"title", task.Title, *edit.Title})
+		task.Title = *edit.Title
+	}
+	if edit.Weight != nil && *edit.Weight != task.TaskWeight {
+		if err := checkWeightCapacity(task, *edit.Weight); err != nil {
+			return err
+		}
+		changes = append(changes, change{"task_weight", strconv.Itoa(task.TaskWeight), strconv.Itoa(*edit.Weight)})
+		task.TaskWeight = *edit.Weight
+	}
+	if edit.Description != nil && *edit.Description != task.Description {
+		changes = append(changes, change{"description", task.Description, *edit.Description})
+		task.Description = *edit.Description
+	}
+	if len(changes) == 0 {
+		output.Printf("タスク #%d に変更はありません", id)
+		return nil
+	}
+
+	if err := store.Update(task); err != nil {
+		return err
+	}
+	fields := make([]string, len(changes))
+	for i, c := range changes {
+		recordEvent(task, "edit", c.field, c.oldValue, c.newValue)
+		fields[i] = c.field
+	}
+	recordTaskOp(fmt.Sprintf("edit #%d", id), id, &before, &task)
+	output.Printf("タスク #%d を更新しました（%s）", id, strings.Join(fields, ", "))
+	return nil
+}
+
+// AddCriterion は受け入れ条件を追加します。
+func AddCriterion(id int, text string) error {
+	if strings.TrimSpace(text) == "" {
+		return fmt.Errorf("受け入れ条件を指定してください")
+	}
+	if err := checkText("受け入れ条件", text); err != nil {
+		return err
+	}
+	if err := updateTask(id, "criteria", func(t *Task) (string, string, string, error) {
+		t.Criteria = append(append([]Criterion{}, t.Criteria...), Criterion{Text: text})
+		return "criteria", "", text, nil
+	}); err != nil {
+		return err
+	}
+	output.Printf("タスク #%d に受け入れ条件を追加しました", id)
+	return nil
+}
+
+// CheckCriterion は n 番目（1 始まり）の受け入れ条件のチェックを付け外しします。
+func CheckCriterion(id int, n int, done bool) error {
+	if err := updateTask(id, "criteria", func(t *Task) (string, string, string, error) {
+		if n < 1 || n > len(t.Criteria) {
+			return "", "", "", fmt.Errorf("受け入れ条件 %d はありません（1〜%d）", n, len(t.Criteria))
+		}
+		criteria := append([]Criterion{}, t.Criteria...)
+		old := criteria[n-1]
+		criteria[n-1].Done = done
+		t.Criteria = criteria
+		return "criteria", criterionLine(old), criterionLine(criteria[n-1]), nil
+	}); err != nil {
+		return err
+	}
+	output.Printf("タスク #%d の受け入れ条件 %d を更新しました", id, n)
+	return nil
+}
+
+// RemoveCriterion は n 番目（1 始まり）の受け入れ条件を削除します。
+func RemoveCriterion(id int, n int) error {
+	if err := updateTask(id, "criteria", func(t *Task) (string, string, string, error) {
+		if n < 1 || n > len(t.Criteria) {
+			return "", "", "", fmt.Errorf("受け入れ条件 %d はありません（1〜%d）", n, len(t.Criteria))
+		}
+		old := t.Criteria[n-1]
+		criteria := append([]Criterion{}, t.Criteria[:n-1]...)
+		t.Criteria = append(criteria, t.Criteria[n:]...)
+		return "criteria", criterionLine(old), "", nil
+	}); err != nil {
+		return err
+	}
+	output.Printf("タスク #%d の受け入れ条件 %d を削除しました", id, n)
+	return nil
+}
+
+func criterionLine(c Criterion) string {
+	if c.Done {
+		return "[x] " + c.Text
+	}
+	return "[ ] " + c.Text
+}
+
+// CommentTask はタスクにコメントを追加します。replyTo を指定するとそのコメントへの返信になります。
+// コメントは複数行で書けるため、改行以外の制御文字を確認します。
+func CommentTask(id int, text string, replyTo int) error {
+	if strings.TrimSpace(text) == "" {
+		return fmt.Errorf("コメントを指定してください")
+	}
+	for _, line := range strings.Split(text, "\n") {
+		if err := checkText("コメント", line); err != nil {
+			return err
+		}
+	}
+	var added Comment
+	if err := updateTask(id, "comment", func(t *Task) (string, string, string, error) {
+		next := 1
+		found := replyTo == 0
+		for _, c := range t.Comments {
+			if c.ID >= next {
+				next = c.ID + 1
+			}
+			if c.ID == replyTo {
+				found = true
+			}
+		}
+		if !found {
+			return "", "", "", fmt.Errorf("コメント %d はありません", replyTo)
+		}
+		added = Comment{ID: next, Author: actor, Time: time.Now(), Text: text, ReplyTo: replyTo}
+		t.Comments = append(append([]Comment{}, t.Comments...), added)
+		return "comments", "", text, nil
+	}); err != nil {
+		return err
+	}
+	output.Printf("タスク #%d にコメント %d を追加しました", id, added.ID)
+	return nil
+}
+
+// ShowTask はタスクの詳細（説明・受け入れ条件・コメント）を表示します。
+func ShowTask(id int) error {
+	tasks, err := store.List()
+	if err != nil {
+		return err
+	}
+	byID := tasksByID(tasks)
+	t, ok := byID[id]
+	if !ok {
+		return notFound(ErrTaskNotFound, "タスク #%d は存在しません", id)
+	}
+
+	output.Printf("#%d %s", t.ID, t.Title)
+	output.Printf("ステータス: %s", statusWithBlockers(t, byID))
+	if isBacklog(t) {
+		output.Println("スプリント: バックログ")
+	} else {
+		output.Printf("スプリント: %d", t.SprintNumber)
+	}
+	output.Printf("ウェイト:   %s", estimation.Label(t.TaskWeight))
+	output.Printf("担当者:     %s", orDash(t.Assignees.String()))
+	if len(t.Labels) > 0 {
+		output.Printf("ラベル:     %s", formatLabels(t.Labels))
+	}
+	if epic := epicOf(t, byID); epic != 0 {
+		output.Printf("エピック:   %d", epic)
+	}
+	if t.ParentID != 0 {
+		output.Printf("親タスク:   #%d %s", t.ParentID, byID[t.ParentID].Title)
+	}
+	if children := childrenOf(tasks)[t.ID]; len(children) > 0 {
+		ids := make([]int, len(children))
+		for i, c := range children {
+			ids[i] = c.ID
+		}
+		output.Printf("子タスク:   %s", formatIDs(ids))
+	}
+	if len(t.DependsOn) > 0 {
+		output.Printf("依存先:     %s", formatIDs(t.DependsOn))
+	}
+
+	if t.Description != "" {
+		output.Println("\n■ 説明")
+		for _, line := range strings.Split(t.Description, "\n") {
+			output.Println("  " + line)
+		}
+	}
+
+	if len(t.Criteria) > 0 {
+		done := len(t.Criteria) - len(t.uncheckedCriteria())
+		output.Printf("\n■ 受け入れ条件 (%d/%d)", done, len(t.Criteria))
+		for i, c := range t.Criteria {
+			output.Printf("  %d. %s", i+1, criterionLine(c))
+		}
+	}
+
+	if len(t.Comments) > 0 {
+		output.Printf("\n■ コメント (%d)", len(t.Comments))
+		printComments(t.Comments, 0, 1)
+	}
+	return nil
+}
+
+// printComments は replyTo への返信を時系列順に、返信の階層に応じて字下げして表示します。
+func printComments(comments []Comment, replyTo int, depth int) {
+	exists := make(map[int]bool, len(comments))
+	for _, c := range comments {
+		exists[c.ID] = true
+	}
+	for _, c := range comments {
+		parent := c.ReplyTo
+		if !exists[parent] {
+			parent = 0 // 返信先が見つからないコメントは最上位に表示
+		}
+		if parent != replyTo || c.ID == replyTo {
+			continue
+		}
+		indent := strings.Repeat("  ", depth)
+		output.Printf("%s[%d] %s (%s)", indent, c.ID, c.Author, c.Time.Format("2006-01-02 15:04"))
+		for _, line := range strings.Split(c.Text, "\n") {
+			output.Printf("%s  %s", indent, line)
+		}
+		printComments(comments, c.ID, depth+1)
+	}
+}
@@ -0,0 +1,187 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEditTask(t *testing.T) {
+	setupStore(t, Task{ID: 1, Title: "a", Status: "todo", TaskWeight: 3, Description: "old"})
+	res := mustRun(t, "edit", "1", "--title", "-b", "--weight", "5", "--desc", "")
+	if got := res.String(); got != "タスク #1 を更新しました（title, task_weight, description）\n" {
+		t.Errorf("output = %q", got)
+	}
+	task := getTask(t, 1)
+	if task.Title != "-b" || task.TaskWeight != 5 || task.Description != "" {
+		t.Errorf("#1 = %q %d %q, want \"-b\" 5 \"\"", task.Title, task.TaskWeight, task.Description)
+	}
+
+	// まとめて1つの操作として記録され、1回の undo で全項目が戻る
+	j, err := loadJournal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(j.Undo) != 1 || j.Undo[0].Desc != "edit #1" {
+		t.Fatalf("journal = %+v, want edit #1 の1件", j.Undo)
+	}
+	mustRun(t, "undo")
+	task = getTask(t, 1)
+	if task.Title != "a" || task.TaskWeight != 3 || task.Description != "old" {
+		t.Errorf("undo 後の #1 = %q %d %q", task.Title, task.TaskWeight, task.Description)
+	}
+}
+
+func TestEditTaskOnlyGivenFields(t *testing.T) {
+	setupStore(t, Task{ID: 1, Title: "a", Status: "todo", TaskWeight: 3, Description: "keep"})
+	mustRun(t, "edit", "1", "--weight", "8")
+	if task := getTask(t, 1); task.Title != "a" || task.TaskWeight != 8 || task.Description != "keep" {
+		t.Errorf("#1 = %q %d %q, want ウェイトだけ変更", task.Title, task.TaskWeight, task.Description)
+	}
+	res := mustRun(t, "edit", "1", "--title", "a")
+	if !strings.Contains(res.String(), "変更はありません") {
+		t.Errorf("変更がないときの output = %q", res.String())
+	}
+}
+
+func TestEditTaskRejectsInvalidValues(t *testing.T) {
+	setupStore(t, Task{ID: 1, Title: "a", Status: "todo", TaskWeight: 3})
+	for _, args := range [][]string{
+		{"edit", "1", "--title", " "},
+		{"edit", "1", "--weight", "4"},
+		{"edit", "9", "--title", "x"},
+	} {
+		if res := run(t, args...); res.Err == nil {
+			t.Errorf("%v がエラーになりません", args)
+		}
+	}
+	if task := getTask(t, 1); task.Title != "a" || task.TaskWeight != 3 {
+		t.Errorf("#1 = %q %d, want 変更なし", task.Title, task.TaskWeight)
+	}
+}
+
+func TestCriteria(t *testing.T) {
+	setupStore(t, Task{ID: 1, Title: "a", Status: "doing", TaskWeight: 3})
+	mustRun(t, "criteria", "1", "add", "画面が", "表示される")
+	mustRun(t, "criteria", "1", "add", "保存できる")
+	mustRun(t, "criteria", "1", "check", "2")
+	if got := getTask(t, 1).Criteria; len(got) != 2 || got[0].Text != "画面が 表示される" || got[0].Done || !got[1].Done {
+		t.Fatalf("受け入れ条件 = %+v", got)
+	}
+
+	tests := []struct {
+		args     []string
+		wantCode int
+	}{
+		{[]string{"criteria", "1", "check", "3"}, exitFailure},
+		{[]string{"criteria", "1", "uncheck", "3"}, exitFailure},
+		{[]string{"criteria", "1", "remove", "3"}, exitFailure},
+		{[]string{"criteria", "1", "check", "0"}, exitUsage},
+		{[]string{"criteria", "1", "check", "x"}, exitUsage},
+		{[]string{"criteria", "1", "add", " "}, exitFailure},
+		{[]string{"criteria", "1", "add", "a\x1b[31m"}, exitFailure},
+		{[]string{"criteria", "9", "add", "x"}, exitNotFound},
+	}
+	for _, tt := range tests {
+		if res := run(t, tt.args...); exitCode(res.Err) != tt.wantCode {
+			t.Errorf("%q の終了コード = %d, want %d（err: %v）", tt.args, exitCode(res.Err), tt.wantCode, res.Err)
+		}
+	}
+	if got := getTask(t, 1).Criteria; len(got) != 2 {
+		t.Errorf("エラーの後の受け入れ条件 = %+v, want 変更なし", got)
+	}
+
+	mustRun(t, "criteria", "1", "remove", "1")
+	if got := getTask(t, 1).Criteria; len(got) != 1 || got[0].Text != "保存できる" {
+		t.Errorf("削除後の受け入れ条件 = %+v", got)
+	}
+}
+
+func TestCompleteWarnsUncheckedCriteria(t *testing.T) {
+	setupStore(t, Task{ID: 1, Title: "a", Status: "doing", TaskWeight: 3, Criteria: []Criterion{
+		{Text: "表示される", Done: true},
+		{Text: "保存できる"},
+	}})
+	res := mustRun(t, "complete", "1")
+	var warnings []string
+	for _, e := range res.Entries {
+		if e.Kind == EntryWarning {
+			warnings = append(warnings, e.Text)
+		}
+	}
+	if len(warnings) != 1 || warnings[0] != "#1 の受け入れ条件が 1 件未チェックです" {
+		t.Errorf("警告 = %q", warnings)
+	}
+	if !strings.Contains(res.String(), "[ ] 保存できる") || strings.Contains(res.String(), "[x] 表示される") {
+		t.Errorf("未チェックの受け入れ条件だけが表示されません:\n%s", res)
+	}
+	if !getTask(t, 1).IsDone() {
+		t.Error("未チェックの受け入れ条件があると完了できません")
+	}
+
+	setupStore(t, Task{ID: 1, Title: "a", Status: "doing", TaskWeight: 3, Criteria: []Criterion{{Text: "表示される", Done: true}}})
+	for _, e := range mustRun(t, "complete", "1").Entries {
+		if e.Kind == EntryWarning {
+			t.Errorf("すべてチェック済みなのに警告されました: %s", e.Text)
+		}
+	}
+}
+
+func TestComments(t *testing.T) {
+	setupStore(t, Task{ID: 1, Title: "a", Status: "todo", TaskWeight: 3})
+	saved := actor
+	actor = "alice"
+	t.Cleanup(func() { actor = saved })
+
+	mustRun(t, "comment", "1", "1行目\n2行目")
+	mustRun(t, "comment", "1", "返信", "--reply", "1")
+	for _, tt := range []struct {
+		args     []string
+		wantCode int
+	}{
+		{[]string{"comment", "1", "x", "--reply", "9"}, exitFailure},
+		{[]string{"comment", "1", "x", "--reply", "-1"}, exitUsage},
+		{[]string{"comment", "1", " "}, exitFailure},
+		{[]string{"comment", "1", "a\x07b"}, exitFailure},
+		{[]string{"comment", "9", "x"}, exitNotFound},
+	} {
+		if res := run(t, tt.args...); exitCode(res.Err) != tt.wantCode {
+			t.Errorf("%q の終了コード = %d, want %d（err: %v）", tt.args, exitCode(res.Err), tt.wantCode, res.Err)
+		}
+	}
+	comments := getTask(t, 1).Comments
+	if len(comments) != 2 || comments[0].Author != "alice" || comments[1].ID != 2 || comments[1].ReplyTo != 1 {
+		t.Errorf("コメント = %+v", comments)
+	}
+}
+
+func TestShowTaskThreadsComments(t *testing.T) {
+	at := time.Date(2024, 5, 1, 9, 0, 0, 0, time.UTC)
+	setupStore(t, Task{ID: 1, Title: "a", Status: "todo", TaskWeight: 3, Comments: []Comment{
+		{ID: 1, Author: "alice", Time: at, Text: "質問"},
+		{ID: 2, Author: "bob", Time: at, Text: "別の話題"},
+		{ID: 3, Author: "bob", Time: at, Text: "回答", ReplyTo: 1},
+		{ID: 4, Author: "alice", Time: at, Text: "了解\nありがとう", ReplyTo: 3},
+		{ID: 5, Author: "carol", Time: at, Text: "補足", ReplyTo: 1},
+		{ID: 6, Author: "carol", Time: at, Text: "返信先なし", ReplyTo: 99},
+	}})
+	out := mustRun(t, "show", "1").String()
+	want := `■ コメント (6)
+  [1] alice (2024-05-01 09:00)
+    質問
+    [3] bob (2024-05-01 09:00)
+      回答
+      [4] alice (2024-05-01 09:00)
+        了解
+        ありがとう
+    [5] carol (2024-05-01 09:00)
+      補足
+  [2] bob (2024-05-01 09:00)
+    別の話題
+  [6] carol (2024-05-01 09:00)
+    返信先なし
+`
+	if i := strings.Index(out, "■ コメント"); i < 0 || out[i:] != want {
+		t.Errorf("コメントの表示 =\n%s\nwant\n%s", out, want)
+	}
+}
@@ -0,0 +1,390 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// quarantineFile は doctor が隔離したレコードの保存先です（todo.json → todo.json.quarantine.json）。
+func quarantineFile() string {
+	return sidecarPath(store.Path(), sidecarQuarantine)
+}
+
+// doctor が修復時に取るアクション
+const (
+	fixNone       = ""
+	fixMerge      = "merge"        // 同一内容の重複レコードを1件にまとめる
+	fixRenumber   = "renumber"     // 重複IDに新しいIDを振り直す
+	fixQuarantine = "quarantine"   // 隔離ファイルへ退避する
+	fixStatus     = "reset-status" // 不明なステータスを初期ステータスに戻す
+)
+
+type problem struct {
+	Index    int // tasks 内の位置（重複IDでも一意に特定するため）
+	ID       int
+	Severity string // "error" または "warning"
+	Message  string
+	Action   string // --fix 時に実行する修復アクション
+}
+
+// checkTasks はタスク一覧を検査して問題点を返します。
+// currentSprint が 0 の場合、スプリント番号の範囲チェックは行いません。
+func checkTasks(tasks []Task, currentSprint int) []problem {
+	problems := []problem{}
+	seen := make(map[int]int) // ID → 最初に現れた位置
+
+	for i, t := range tasks {
+		if first, ok := seen[t.ID]; ok {
+			if reflect.DeepEqual(tasks[first], t) {
+				problems = append(problems, problem{i, t.ID, "error",
+					fmt.Sprintf("ID %d が重複しています（内容も同一）", t.ID), fixMerge})
+			} else {
+				problems = append(problems, problem{i, t.ID, "error",
+					fmt.Sprintf("ID %d が重複しています", t.ID), fixRenumber})
+			}
+		} else {
+			seen[t.ID] = i
+		}
+
+		if strings.TrimSpace(t.Title) == "" {
+			problems = append(problems, problem{i, t.ID, "error", "title がありません", fixQuarantine})
+		}
+		// sprint_number がない（0 の）タスクはバックログなので問題なし
+		if t.SprintNumber < 0 {
+			problems = append(problems, problem{i, t.ID, "error",
+				fmt.Sprintf("sprint_number が負の値です（%d）", t.SprintNumber), fixQuarantine})
+		} else if currentSprint > 0 && t.SprintNumber > currentSprint {
+			problems = append(problems, problem{i, t.ID, "warning",
+				fmt.Sprintf("sprint_number %d が現在のスプリント %d より先です", t.SprintNumber, currentSprint), fixNone})
+		}
+		if !workflow.HasStatus(t.Status) {
+			problems = append(problems, problem{i, t.ID, "error",
+				fmt.Sprintf("不明なステータスです（%s）", t.Status), fixStatus})
+		}
+		if t.TaskWeight < 0 {
+			problems = append(problems, problem{i, t.ID, "error",
+				fmt.Sprintf("task_weight が負の値です（%d）", t.TaskWeight), fixQuarantine})
+		} else if t.TaskWeight == 0 {
+			problems = append(problems, problem{i, t.ID, "warning", "task_weight がありません", fixNone})
+		} else if !estimation.Allows(t.TaskWeight) {
+			problems = append(problems, problem{i, t.ID, "warning",
+				fmt.Sprintf("task_weight %d が見積もりスケール（%s）にありません（estimate で見直してください）", t.TaskWeight, estimation), fixNone})
+		}
+	}
+	return problems
+}
+
+// checkTaskSprints はタスクが存在しないスプリントを参照していないか検査します。
+// スプリントの記録が1件もない（旧形式の）場合は検査しません。
+func checkTaskSprints(tasks []Task, sprints []Sprint) []problem {
+	problems := []problem{}
+	if len(sprints) == 0 {
+		return problems
+	}
+	exists := make(map[int]bool)
+	for _, sp := range sprints {
+		exists[sp.Number] = true
+	}
+	for i, t := range tasks {
+		if t.SprintNumber > 0 && !exists[t.SprintNumber] {
+			problems = append(problems, problem{i, t.ID, "warning",
+				fmt.Sprintf("スプリント %d の記録がありません（migrate で作成できます）", t.SprintNumber), fixNone})
+		}
+	}
+	return problems
+}
+
+// checkTaskLinks はタスクが存在しない親タスク・エピック・依存先を参照していないか検査します。
+func checkTaskLinks(tasks []Task, epics []Epic) []problem {
+	problems := []problem{}
+	taskExists := make(map[int]bool)
+	for _, t := range tasks {
+		taskExists[t.ID] = true
+	}
+	epicExists := make(map[int]bool)
+	for _, e := range epics {
+		epicExists[e.ID] = true
+	}
+	for i, t := range tasks {
+		if t.ParentID != 0 && !taskExists[t.ParentID] {
+			problems = append(problems, problem{i, t.ID, "warning",
+				fmt.Sprintf("親タスク #%d が存在しません（最上位のタスクとして表示されます）", t.ParentID), fixNone})
+		}
+		for _, dep := range t.DependsOn {
+			if !taskExists[dep] {
+				problems = append(problems, problem{i, t.ID, "warning",
+					fmt.Sprintf("依存先のタスク #%d が存在しません（完了済みとして扱います）", dep), fixNone})
+			}
+		}
+		if t.EpicID != 0 && !epicExists[t.EpicID] {
+			problems = append(problems, problem{i, t.ID, "warning",
+				fmt.Sprintf("エピック %d の記録がありません", t.EpicID), fixNone})
+		}
+	}
+	return problems
+}
+
+// Doctor はタスクデータを検査し、fix または interactive が指定されていれば修復します。
+// 修復されずに残ったエラー（severity が error の問題）があればエラーを返します。
+func Doctor(fix, interactive bool) error {
+	unlock, err := store.Lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	tasks, err := store.List()
+	if err != nil {
+		return err
+	}
+	current, err := currentSprint()
+	if err != nil {
+		return err
+	}
+
+	sprints, err := store.ListSprints()
+	if err != nil {
+		return err
+	}
+	problems := checkTasks(tasks, current)
+	epics, err := store.ListEpics()
+	if err != nil {
+		return err
+	}
+	problems = append(problems, checkTaskSprints(tasks, sprints)...)
+	problems = append(problems, checkTaskLinks(tasks, epics)...)
+	if len(problems) == 0 {
+		output.Println("問題は見つかりませんでした。")
+		return nil
+	}
+
+	table := &Table{Header: []string{"#", "ID", "Title", "Severity", "Problem", "Fix"}}
+	for n, p := range problems {
+		action := p.Action
+		if action == fixNone {
+			action = "-"
+		}
+		table.Append([]string{
+			strconv.Itoa(n + 1),
+			strconv.Itoa(p.ID),
+			tasks[p.Index].Title,
+			p.Severity,
+			p.Message,
+			action,
+		})
+	}
+	output.AddTable(table)
+
+	if !fix && !interactive {
+		output.Println("修復するには doctor --fix（一括）または doctor --interactive（対話）を実行してください。")
+		return unfixedErrors(problems)
+	}
+
+	// 各レコードに適用するアクションを決める
+	actions := make(fixActions)
+	for n, p := range problems {
+		action := p.Action
+		if interactive {
+			action = askFixAction(stdin, n+1, p)
+		}
+		if action != fixNone {
+			actions.add(p.Index, action)
+		}
+	}
+	if len(actions) == 0 {
+		output.Println("修復は行いませんでした。")
+		return unfixedErrors(problems)
+	}
+
+	fixed, quarantined := applyFixes(tasks, actions)
+	if len(quarantined) > 0 {
+		if err := appendQuarantine(quarantined); err != nil {
+			return err
+		}
+	}
+	if err := store.ReplaceAll(fixed); err != nil {
+		return err
+	}
+	recordBulkOp("doctor", tasks, fixed)
+	output.Printf("%d 件のレコードを修復しました（隔離: %d 件 → %s）", len(actions), len(quarantined), quarantineFile())
+	// スキップした問題や、1つの修復では解消しなかった問題が残っていないか、修復後のデータを検査し直す
+	return unfixedErrors(checkTasks(fixed, current))
+}
+
+// fixActions はレコード（tasks 内の位置）ごとに適用する修復アクションです。
+// 1つのレコードに複数の問題がある場合は、ID の振り直しとステータスの初期化のようにすべて適用します。
+// 隔離と統合はレコード自体を取り除くため、ほかのアクションより優先します（隔離が最優先）。
+type fixActions map[int][]string
+
+func (a fixActions) add(index int, action string) {
+	switch {
+	case a.has(index, fixQuarantine):
+	case action == fixQuarantine, action == fixMerge:
+		a[index] = []string{action}
+	case a.has(index, fixMerge), a.has(index, action):
+	default:
+		a[index] = append(a[index], action)
+	}
+}
+
+func (a fixActions) has(index int, action string) bool {
+	for _, x := range a[index] {
+		if x == action {
+			return true
+		}
+	}
+	return false
+}
+
+// unfixedErrors は problems に残っているエラー（severity が error の問題）の件数をエラーとして返します。
+func unfixedErrors(problems []problem) error {
+	n := 0
+	for _, p := range problems {
+		if p.Severity == "error" {
+			n++
+		}
+	}
+	if n > 0 {
+		return fmt.Errorf("修復されていないエラーが %d 件あります", n)
+	}
+	return nil
+}
+
+// askFixAction は対話モードで問題ごとの修復方法を尋ねます。
+func askFixAction(sc *bufio.Scanner, n int, p problem) string {
+	suggested := p.Action
+	if suggested == fixNone {
+		suggested = "skip"
+	}
+	choices := "q=quarantine, s=skip"
+	switch p.Action {
+	case fixMerge:
+		choices = "r=renumber, m=merge, " + choices
+	case fixRenumber:
+		choices = "r=renumber, " + choices
+	}
+	for {
+		fmt.Printf("#%d ID %d: %s\n  [Enter]=%s, %s > ", n, p.ID, p.Message, suggested, choices)
+		if !sc.Scan() {
+			return fixNone
+		}
+		switch strings.TrimSpace(sc.Text()) {
+		case "":
+			return p.Action
+		case "r":
+			if p.Action != fixMerge && p.Action != fixRenumber {
+				output.Println("renumber は重複IDの問題にのみ使用できます")
+				continue
+			}
+			return fixRenumber
+		case "m":
+			// 内容の異なるレコードをまとめるとデータが失われるため、同一内容の重複にのみ許可する
+			if p.Action != fixMerge {
+				output.Println("merge は内容が同一の重複IDの問題にのみ使用できます")
+				continue
+			}
+			return fixMerge
+		case "q":
+			return fixQuarantine
+		case "s":
+			return fixNone
+		default:
+			output.Println("r / m / q / s のいずれかを入力してください")
+		}
+	}
+}
+
+// applyFixes はアクションを適用したタスク一覧と、隔離されたタスクを返します。
+// ID を振り直したレコードを指す親タスク・依存先は新しい ID に書き換えます。重複した ID への参照は、
+// タスクが追加順に並んでいることから、参照元より前にある同じ ID のうち最も近いレコードを指すものとみなします。
+func applyFixes(tasks []Task, actions fixActions) ([]Task, []Task) {
+	fixed := make([]Task, 0, len(tasks))
+	quarantined := []Task{}
+	newID := nextID(tasks)
+	renumbered := make(map[int]bool) // 振り直しのあった元の ID
+	for i, t := range tasks {
+		if actions.has(i, fixRenumber) {
+			renumbered[t.ID] = true
+		}
+	}
+	resolved := make(map[int]int) // 元の ID → その位置で参照されているレコードの ID
+
+	for i, t := range tasks {
+		t = relinkTask(t, renumbered, resolved)
+		if renumbered[tasks[i].ID] && !actions.has(i, fixMerge) && !actions.has(i, fixQuarantine) {
+			resolved[tasks[i].ID] = tasks[i].ID
+		}
+		if actions.has(i, fixMerge) {
+			recordEvent(t, "doctor", "task", taskSnapshot(t), "merged")
+			continue // 先に現れた同一IDのレコードに統合
+		}
+		if actions.has(i, fixQuarantine) {
+			recordEvent(t, "doctor", "task", taskSnapshot(t), "quarantined")
+			quarantined = append(quarantined, t)
+			continue
+		}
+		if actions.has(i, fixRenumber) {
+			output.Printf("ID %d (%s) → ID %d に振り直しました", t.ID, t.Title, newID)
+			recordEvent(t, "doctor", "id", strconv.Itoa(t.ID), strconv.Itoa(newID))
+			resolved[t.ID] = newID
+			t.ID = newID
+			newID++
+		}
+		if actions.has(i, fixStatus) {
+			recordEvent(t, "doctor", "status", t.Status, workflow.Initial)
+			t.Status = workflow.Initial
+		}
+		fixed = append(fixed, t)
+	}
+	return fixed, quarantined
+}
+
+// relinkTask は振り直した ID を指す親タスク・依存先を、その位置で有効な ID に書き換えます。
+func relinkTask(t Task, renumbered map[int]bool, resolved map[int]int) Task {
+	relink := func(id int) int {
+		if to, ok := resolved[id]; ok && renumbered[id] {
+			return to
+		}
+		return id
+	}
+	if to := relink(t.ParentID); to != t.ParentID {
+		output.Printf("タスク #%d (%s) の親タスクを #%d → #%d に付け替えました", t.ID, t.Title, t.ParentID, to)
+		recordEvent(t, "doctor", "parent", strconv.Itoa(t.ParentID), strconv.Itoa(to))
+		t.ParentID = to
+	}
+	deps := append([]int(nil), t.DependsOn...)
+	for i, dep := range deps {
+		if to := relink(dep); to != dep {
+			output.Printf("タスク #%d (%s) の依存先を #%d → #%d に付け替えました", t.ID, t.Title, dep, to)
+			recordEvent(t, "doctor", "depends_on", strconv.Itoa(dep), strconv.Itoa(to))
+			deps[i] = to
+			t.DependsOn = deps
+		}
+	}
+	return t
+}
+
+func appendQuarantine(tasks []Task) error {
+	existing := []Task{}
+	file, err := os.Open(quarantineFile())
+	if err == nil {
+		err = json.NewDecoder(file).Decode(&existing)
+		file.Close()
+		if err != nil {
+			return err
+		}
+	} else if !os.IsNotExist(err) {
+		return err
+	}
+
+	existing = append(existing, tasks...)
+	return writeFileAtomic(quarantineFile(), func(w io.Writer) error {
+		return json.NewEncoder(w).Encode(existing)
+	})
+}
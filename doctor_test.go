@@ -0,0 +1,254 @@
+package main
+
+import (
+	"bufio"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCheckTasks(t *testing.T) {
+	tests := []struct {
+		name          string
+		tasks         []Task
+		currentSprint int
+		want          []problem // Message は比較しない
+	}{
+		{
+			name:  "問題なし",
+			tasks: []Task{{ID: 1, Title: "a", Status: "todo", SprintNumber: 1, TaskWeight: 3}},
+		},
+		{
+			name: "内容も同一の重複ID",
+			tasks: []Task{
+				{ID: 1, Title: "a", Status: "todo", TaskWeight: 3},
+				{ID: 1, Title: "a", Status: "todo", TaskWeight: 3},
+			},
+			want: []problem{{Index: 1, ID: 1, Severity: "error", Action: fixMerge}},
+		},
+		{
+			name: "内容の異なる重複ID",
+			tasks: []Task{
+				{ID: 1, Title: "a", Status: "done", TaskWeight: 3},
+				{ID: 1, Title: "a", Status: "todo", TaskWeight: 3},
+			},
+			want: []problem{{Index: 1, ID: 1, Severity: "error", Action: fixRenumber}},
+		},
+		{
+			name:  "タイトルなし",
+			tasks: []Task{{ID: 1, Title: " ", Status: "todo", TaskWeight: 3}},
+			want:  []problem{{Index: 0, ID: 1, Severity: "error", Action: fixQuarantine}},
+		},
+		{
+			name:  "負のスプリント番号",
+			tasks: []Task{{ID: 1, Title: "a", Status: "todo", SprintNumber: -1, TaskWeight: 3}},
+			want:  []problem{{Index: 0, ID: 1, Severity: "error", Action: fixQuarantine}},
+		},
+		{
+			name:          "現在より先のスプリント",
+			tasks:         []Task{{ID: 1, Title: "a", Status: "todo", SprintNumber: 5, TaskWeight: 3}},
+			currentSprint: 2,
+			want:          []problem{{Index: 0, ID: 1, Severity: "warning", Action: fixNone}},
+		},
+		{
+			name:  "不明なステータス",
+			tasks: []Task{{ID: 1, Title: "a", Status: "wip", TaskWeight: 3}},
+			want:  []problem{{Index: 0, ID: 1, Severity: "error", Action: fixStatus}},
+		},
+		{
+			name:  "負のウェイト",
+			tasks: []Task{{ID: 1, Title: "a", Status: "todo", TaskWeight: -3}},
+			want:  []problem{{Index: 0, ID: 1, Severity: "error", Action: fixQuarantine}},
+		},
+		{
+			name:  "ウェイトなし",
+			tasks: []Task{{ID: 1, Title: "a", Status: "todo"}},
+			want:  []problem{{Index: 0, ID: 1, Severity: "warning", Action: fixNone}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := checkTasks(tt.tasks, tt.currentSprint)
+			for i := range got {
+				got[i].Message = ""
+			}
+			if len(got) == 0 && len(tt.want) == 0 {
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("checkTasks() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAskFixAction(t *testing.T) {
+	tests := []struct {
+		name   string
+		action string // 問題の推奨アクション
+		input  string
+		want   string
+	}{
+		{"同一内容の重複は merge できる", fixMerge, "m\n", fixMerge},
+		{"同一内容の重複は renumber もできる", fixMerge, "r\n", fixRenumber},
+		{"内容の異なる重複は merge できない", fixRenumber, "m\n\n", fixRenumber},
+		{"内容の異なる重複は renumber できる", fixRenumber, "r\n", fixRenumber},
+		{"重複以外は renumber / merge できない", fixQuarantine, "r\nm\ns\n", fixNone},
+		{"重複以外も隔離はできる", fixStatus, "q\n", fixQuarantine},
+		{"Enter は推奨アクション", fixStatus, "\n", fixStatus},
+		{"入力の終わりは何もしない", fixQuarantine, "", fixNone},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sc := bufio.NewScanner(strings.NewReader(tt.input))
+			p := problem{ID: 1, Severity: "error", Message: "test", Action: tt.action}
+			if got := askFixAction(sc, 1, p); got != tt.want {
+				t.Errorf("askFixAction() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDoctorExitCode(t *testing.T) {
+	setupStore(t,
+		Task{ID: 1, Title: "a", Status: "todo", TaskWeight: 3},
+		Task{ID: 2, Title: "", Status: "todo", TaskWeight: 3},
+	)
+
+	res := run(t, "doctor")
+	if got := exitCode(res.Err); got != exitFailure {
+		t.Fatalf("修復前: exit code = %d, want %d", got, exitFailure)
+	}
+	res = run(t, "doctor", "--fix")
+	if res.Err != nil {
+		t.Fatalf("doctor --fix: %v", res.Err)
+	}
+	res = run(t, "doctor")
+	if res.Err != nil {
+		t.Fatalf("修復後: %v", res.Err)
+	}
+	if _, err := os.Stat("todo.json.quarantine.json"); err != nil {
+		t.Errorf("隔離ファイルがありません: %v", err)
+	}
+}
+
+func TestDoctorSkippedErrorFails(t *testing.T) {
+	setupStore(t, Task{ID: 1, Title: "a", Status: "wip", TaskWeight: 3})
+	setInput(t, "s\n")
+
+	res := run(t, "doctor", "--interactive")
+	if got := exitCode(res.Err); got != exitFailure {
+		t.Errorf("スキップしたエラーが残る場合の exit code = %d, want %d", got, exitFailure)
+	}
+	if got := getTask(t, 1).Status; got != "wip" {
+		t.Errorf("スキップしたタスクのステータス = %q, want wip", got)
+	}
+}
+
+func TestDoctorFixMergeAndRenumber(t *testing.T) {
+	setupStore(t,
+		Task{ID: 1, Title: "a", Status: "todo", TaskWeight: 3},
+		Task{ID: 1, Title: "a", Status: "todo", TaskWeight: 3},
+		Task{ID: 2, Title: "b", Status: "done", TaskWeight: 3},
+		Task{ID: 2, Title: "b", Status: "todo", TaskWeight: 5},
+	)
+	mustRun(t, "doctor", "--fix")
+
+	got := loadTasks(t)
+	want := []struct {
+		id     int
+		status string
+		weight int
+	}{{1, "todo", 3}, {2, "done", 3}, {3, "todo", 5}}
+	if len(got) != len(want) {
+		t.Fatalf("修復後のタスク数 = %d, want %d: %+v", len(got), len(want), got)
+	}
+	for i, w := range want {
+		if got[i].ID != w.id || got[i].Status != w.status || got[i].TaskWeight != w.weight {
+			t.Errorf("tasks[%d] = #%d %s %d, want #%d %s %d", i, got[i].ID, got[i].Status, got[i].TaskWeight, w.id, w.status, w.weight)
+		}
+	}
+}
+
+func TestDoctorRenumberRelinks(t *testing.T) {
+	setupStore(t,
+		Task{ID: 1, Title: "base", Status: "todo", TaskWeight: 3},
+		Task{ID: 2, Title: "first", Status: "todo", TaskWeight: 3},
+		Task{ID: 3, Title: "child of first", Status: "todo", TaskWeight: 1, ParentID: 2},
+		Task{ID: 2, Title: "second", Status: "todo", TaskWeight: 5},
+		Task{ID: 4, Title: "child of second", Status: "todo", TaskWeight: 1, ParentID: 2, DependsOn: []int{2, 1}},
+	)
+	mustRun(t, "doctor", "--fix")
+
+	// 2件目の #2 は #5 に振り直され、その後に追加された子タスクの参照も付け替わる
+	if got := getTask(t, 5).Title; got != "second" {
+		t.Fatalf("#5 = %q, want second", got)
+	}
+	if got := getTask(t, 2).Title; got != "first" {
+		t.Errorf("#2 = %q, want first", got)
+	}
+	if got := getTask(t, 3).ParentID; got != 2 {
+		t.Errorf("#3 の親 = %d, want 2", got)
+	}
+	child := getTask(t, 4)
+	if child.ParentID != 5 {
+		t.Errorf("#4 の親 = %d, want 5", child.ParentID)
+	}
+	if !reflect.DeepEqual(child.DependsOn, []int{5, 1}) {
+		t.Errorf("#4 の依存先 = %v, want [5 1]", child.DependsOn)
+	}
+}
+
+func TestDoctorFixDuplicateWithUnknownStatus(t *testing.T) {
+	// 2件目の #1 は ID の重複と不明なステータスの両方の問題を持つ
+	setupStore(t,
+		Task{ID: 1, Title: "a", Status: "todo", TaskWeight: 3},
+		Task{ID: 1, Title: "b", Status: "wip", TaskWeight: 3},
+	)
+	mustRun(t, "doctor", "--fix")
+
+	if got := getTask(t, 2); got.Title != "b" || got.Status != "todo" {
+		t.Errorf("#2 = %q %s, want b todo", got.Title, got.Status)
+	}
+	if res := run(t, "doctor"); res.Err != nil {
+		t.Errorf("修復後の doctor: %v\n%s", res.Err, res)
+	}
+}
+
+func TestDoctorInteractivePartialSkipFails(t *testing.T) {
+	setupStore(t,
+		Task{ID: 1, Title: "a", Status: "todo", TaskWeight: 3},
+		Task{ID: 1, Title: "b", Status: "wip", TaskWeight: 3},
+	)
+	// ID の重複は振り直し、不明なステータスはスキップ
+	setInput(t, "r\ns\n")
+
+	res := run(t, "doctor", "--interactive")
+	if got := exitCode(res.Err); got != exitFailure {
+		t.Errorf("exit code = %d, want %d", got, exitFailure)
+	}
+	if got := getTask(t, 2); got.Title != "b" || got.Status != "wip" {
+		t.Errorf("#2 = %q %s, want b wip", got.Title, got.Status)
+	}
+}
+
+func TestDoctorFixCanBeUndone(t *testing.T) {
+	original := []Task{
+		{ID: 1, Title: "a", Status: "todo", TaskWeight: 3},
+		{ID: 1, Title: "b", Status: "wip", TaskWeight: 3},
+		{ID: 2, Title: "", Status: "todo", TaskWeight: 3},
+	}
+	setupStore(t, original...)
+	want := loadTasks(t)
+	mustRun(t, "doctor", "--fix")
+
+	mustRun(t, "undo")
+	if got := loadTasks(t); !sameJSON(got, want) {
+		t.Fatalf("undo 後のタスク = %+v, want %+v", got, want)
+	}
+	mustRun(t, "redo")
+	if got := len(loadTasks(t)); got != 2 {
+		t.Errorf("redo 後のタスク数 = %d, want 2", got)
+	}
+}
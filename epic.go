@@ -0,0 +1,341 @@
+package main
+
+import (
+	"fmt"
+	"image/color"
+	"strconv"
+	"strings"
+	"time"
+
+	"gonum.org/v1/plot"
+	"gonum.org/v1/plot/plotter"
+	"gonum.org/v1/plot/vg"
+)
+
+// Epic は複数のタスクをまとめる大きな機能単位です。タスクとは Task.EpicID で紐づきます。
+// 子タスク（Task.ParentID を持つタスク）は親のエピックに属します。
+type Epic struct {
+	ID          int        `json:"id"`
+	Title       string     `json:"title"`
+	Description string     `json:"description,omitempty"`
+	CreatedAt   *time.Time `json:"created_at,omitempty"`
+}
+
+// taskNode はツリー表示用のタスクと階層の深さです。
+type taskNode struct {
+	Task  Task
+	Depth int
+}
+
+// childrenOf は親 ID → 子タスクの一覧を返します。
+func childrenOf(tasks []Task) map[int][]Task {
+	children := make(map[int][]Task)
+	for _, t := range tasks {
+		if t.ParentID != 0 {
+			children[t.ParentID] = append(children[t.ParentID], t)
+		}
+	}
+	return children
+}
+
+// taskTree はタスクを親 → 子の順に並べ替えます。親が見つからないタスクは最上位に置きます。
+// 重複した ID のレコードも隠さずに表示するよう、訪問済みかどうかは tasks 内の位置で管理します。
+func taskTree(tasks []Task) []taskNode {
+	exists := make(map[int]bool)
+	children := make(map[int][]int) // 親 ID → 子タスクの位置
+	for i, t := range tasks {
+		exists[t.ID] = true
+		if t.ParentID != 0 {
+			children[t.ParentID] = append(children[t.ParentID], i)
+		}
+	}
+
+	nodes := make([]taskNode, 0, len(tasks))
+	visited := make([]bool, len(tasks))
+	var walk func(i, depth int)
+	walk = func(i, depth int) {
+		if visited[i] {
+			return // 親子関係が循環している場合の保険
+		}
+		visited[i] = true
+		nodes = append(nodes, taskNode{tasks[i], depth})
+		for _, c := range children[tasks[i].ID] {
+			walk(c, depth+1)
+		}
+	}
+	for i, t := range tasks {
+		if t.ParentID == 0 || !exists[t.ParentID] {
+			walk(i, 0)
+		}
+	}
+	for i := range tasks {
+		if !visited[i] {
+			walk(i, 0)
+		}
+	}
+	return nodes
+}
+
+// treeTitle は階層に応じてタイトルを字下げします。
+func treeTitle(n taskNode) string {
+	if n.Depth == 0 {
+		return n.Task.Title
+	}
+	return strings.Repeat("  ", n.Depth-1) + "└ " + n.Task.Title
+}
+
+// rollupWeight はタスク自身と子孫タスクのウェイトの合計を返します。
+func rollupWeight(t Task, children map[int][]Task) int {
+	total := t.TaskWeight
+	for _, c := range children[t.ID] {
+		total += rollupWeight(c, children)
+	}
+	return total
+}
+
+// epicOf はタスクが属するエピックの ID を返します。タスクに設定がなければ親をたどります。
+func epicOf(t Task, byID map[int]Task) int {
+	for depth := 0; depth < len(byID); depth++ {
+		if t.EpicID != 0 || t.ParentID == 0 {
+			return t.EpicID
+		}
+		parent, ok := byID[t.ParentID]
+		if !ok {
+			return 0
+		}
+		t = parent
+	}
+	return 0
+}
+
+// epicProgress はエピックごとの完了ウェイトと合計ウェイト（子タスクを含む）を集計します。
+// label を指定するとそのラベルのタスクだけを集計します。エピックは親をたどって決まるため、
+// ラベルの付いていない親を含むすべてのタスクから求めます。
+func epicProgress(tasks []Task, label string) (done map[int]int, total map[int]int) {
+	byID := tasksByID(tasks)
+	done, total = make(map[int]int), make(map[int]int)
+	for _, t := range filterByLabel(tasks, label) {
+		id := epicOf(t, byID)
+		if id == 0 {
+			continue
+		}
+		total[id] += t.TaskWeight
+		if t.IsDone() {
+			done[id] += t.TaskWeight
+		}
+	}
+	return done, total
+}
+
+func progressRate(done, total int) int {
+	if total == 0 {
+		return 0
+	}
+	return done * 100 / total
+}
+
+// resolveTaskLinks は追加するタスクの親とエピックを確認し、エピックが省略されていれば親から引き継ぎます。
+func resolveTaskLinks(parentID int, epicID int) (int, error) {
+	if parentID != 0 {
+		parent, err := store.Get(parentID)
+		if err == ErrTaskNotFound {
+			return 0, notFound(err, "親タスク #%d は存在しません", parentID)
+		}
+		if err != nil {
+			return 0, err
+		}
+		tasks, err := store.List()
+		if err != nil {
+			return 0, err
+		}
+		byID := tasksByID(tasks)
+		parentEpic := epicOf(parent, byID)
+		if epicID == 0 {
+			return parentEpic, nil
+		}
+		if parentEpic != 0 && parentEpic != epicID {
+			return 0, fmt.Errorf("親タスク #%d はエピック %d に属しています", parentID, parentEpic)
+		}
+	}
+	if epicID != 0 {
+		if _, err := store.GetEpic(epicID); err == ErrEpicNotFound {
+			return 0, notFound(err, "エピック %d は存在しません（epic create で作成してください）", epicID)
+		} else if err != nil {
+			return 0, err
+		}
+	}
+	return epicID, nil
+}
+
+// CreateEpic はエピックを作成します。
+func CreateEpic(title string, description string) error {
+	if err := checkText("タイトル", title); err != nil {
+		return err
+	}
+	unlock, err := store.Lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	epics, err := store.ListEpics()
+	if err != nil {
+		return err
+	}
+	id := 1
+	for _, e := range epics {
+		if e.ID >= id {
+			id = e.ID + 1
+		}
+	}
+	epic := Epic{ID: id, Title: title, Description: description, CreatedAt: timePtr(time.Now())}
+	if err := store.SaveEpic(epic); err != nil {
+		return err
+	}
+	output.Printf("エピック %d「%s」を作成しました", id, title)
+	return nil
+}
+
+// LinkEpic は既存のタスクをエピックに紐づけます。epicID が 0 の場合は紐づけを外します。
+func LinkEpic(epicID int, taskID int) error {
+	unlock, err := store.Lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	if epicID != 0 {
+		if _, err := store.GetEpic(epicID); err == ErrEpicNotFound {
+			return notFound(err, "エピック %d は存在しません", epicID)
+		} else if err != nil {
+			return err
+		}
+	}
+	task, err := findTask(taskID)
+	if err != nil {
+		return err
+	}
+	if task.ParentID != 0 {
+		return fmt.Errorf("タスク #%d は子タスクのため、親タスク #%d のエピックに属します", taskID, task.ParentID)
+	}
+
+	before := task
+	task.EpicID = epicID
+	if err := store.Update(task); err != nil {
+		return err
+	}
+	recordEvent(task, "epic", "epic_id", strconv.Itoa(before.EpicID), strconv.Itoa(epicID))
+	recordTaskOp(fmt.Sprintf("epic #%d", taskID), taskID, &before, &task)
+	if epicID == 0 {
+		output.Printf("タスク #%d をエピックから外しました", taskID)
+	} else {
+		output.Printf("タスク #%d をエピック %d に追加しました", taskID, epicID)
+	}
+	return nil
+}
+
+// ListEpics はエピックの一覧と完了率を表示します。
+func ListEpics() error {
+	epics, err := store.ListEpics()
+	if err != nil {
+		return err
+	}
+	tasks, err := store.List()
+	if err != nil {
+		return err
+	}
+	done, total := epicProgress(tasks, "")
+
+	table := &Table{Header: []string{"Epic", "Title", "Done", "Total", "Progress"}}
+	for _, e := range epics {
+		table.Append([]string{
+			strconv.Itoa(e.ID),
+			e.Title,
+			strconv.Itoa(done[e.ID]),
+			strconv.Itoa(total[e.ID]),
+			fmt.Sprintf("%d%%", progressRate(done[e.ID], total[e.ID])),
+		})
+	}
+	output.AddTable(table)
+	return nil
+}
+
+// ShowEpic はエピックの詳細と、属するタスクをツリーで表示します。
+func ShowEpic(id int) error {
+	epic, err := store.GetEpic(id)
+	if err == ErrEpicNotFound {
+		return notFound(err, "エピック %d は存在しません", id)
+	}
+	if err != nil {
+		return err
+	}
+	tasks, err := store.List()
+	if err != nil {
+		return err
+	}
+	done, total := epicProgress(tasks, "")
+
+	output.Printf("エピック %d: %s", epic.ID, epic.Title)
+	if epic.Description != "" {
+		output.Printf("説明:   %s", epic.Description)
+	}
+	output.Printf("進捗:   %d/%d (%d%%)", done[id], total[id], progressRate(done[id], total[id]))
+
+	byID := tasksByID(tasks)
+	children := childrenOf(tasks)
+	table := &Table{Header: []string{"ID", "Title", "Sprint", "Weight", "Assignees", "Status"}}
+	for _, n := range taskTree(tasks) {
+		if epicOf(n.Task, byID) != id {
+			continue
+		}
+		table.Append([]string{
+			strconv.Itoa(n.Task.ID),
+			treeTitle(n),
+			strconv.Itoa(n.Task.SprintNumber),
+			weightWithRollup(n.Task, children),
+			n.Task.Assignees.String(),
+			statusWithBlockers(n.Task, byID),
+		})
+	}
+	output.AddTable(table)
+	return nil
+}
+
+// weightWithRollup は子タスクがあれば "3 (計 11)" の形式で子孫を含む合計も表示します。
+func weightWithRollup(t Task, children map[int][]Task) string {
+	if len(children[t.ID]) == 0 {
+		return strconv.Itoa(t.TaskWeight)
+	}
+	return fmt.Sprintf("%d (計 %d)", t.TaskWeight, rollupWeight(t, children))
+}
+
+// plotEpicProgress はエピックごとの完了率の棒グラフを epic_progress.png に出力します。
+func plotEpicProgress(epics []Epic, done map[int]int, total map[int]int) error {
+	labels := make([]string, len(epics))
+	vals := make(plotter.Values, len(epics))
+	for i, e := range epics {
+		labels[i] = fmt.Sprintf("#%d %s", e.ID, e.Title)
+		vals[i] = float64(progressRate(done[e.ID], total[e.ID]))
+	}
+
+	p := plot.New()
+	p.Title.Text = "Progress by Epic"
+	p.Y.Label.Text = "Progress (%)"
+	p.NominalX(labels...)
+
+	bar, err := plotter.NewBarChart(vals, vg.Points(30))
+	if err != nil {
+		return err
+	}
+	bar.LineStyle.Width = vg.Length(0)
+	bar.Color = color.RGBA{153, 102, 255, 255} // 紫
+	p.Add(bar)
+	p.Y.Max = 100
+
+	p.X.Tick.Label.Rotation = 0.5
+	p.X.Padding = vg.Points(40)
+	p.X.Min = -0.5
+	p.X.Max = float64(len(labels)) - 0.5
+
+	return p.Save(8*vg.Inch, 4*vg.Inch, "epic_progress.png")
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+// setupEpics は保存先にエピックを用意します。
+func setupEpics(t *testing.T, tasks []Task, epics ...Epic) {
+	t.Helper()
+	setupStore(t, tasks...)
+	for _, e := range epics {
+		if err := store.SaveEpic(e); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestTaskTree(t *testing.T) {
+	tests := []struct {
+		name  string
+		tasks []Task
+		want  []string // "ID:深さ"
+	}{
+		{
+			name: "親 → 子の順",
+			tasks: []Task{
+				{ID: 1}, {ID: 2, ParentID: 1}, {ID: 3}, {ID: 4, ParentID: 2}, {ID: 5, ParentID: 1},
+			},
+			want: []string{"1:0", "2:1", "4:2", "5:1", "3:0"},
+		},
+		{
+			name:  "親が見つからないタスクは最上位",
+			tasks: []Task{{ID: 2, ParentID: 9}, {ID: 3, ParentID: 2}, {ID: 1}},
+			want:  []string{"2:0", "3:1", "1:0"},
+		},
+		{
+			name:  "循環していても各タスクを1回ずつ表示",
+			tasks: []Task{{ID: 1, ParentID: 2}, {ID: 2, ParentID: 1}, {ID: 3}},
+			want:  []string{"3:0", "1:0", "2:1"},
+		},
+		{
+			name:  "重複した ID も隠さない",
+			tasks: []Task{{ID: 1}, {ID: 1}, {ID: 2, ParentID: 1}},
+			want:  []string{"1:0", "2:1", "1:0"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := []string{}
+			for _, n := range taskTree(tt.tasks) {
+				got = append(got, fmt.Sprintf("%d:%d", n.Task.ID, n.Depth))
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("taskTree = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRollupWeight(t *testing.T) {
+	tasks := []Task{
+		{ID: 1, TaskWeight: 1},
+		{ID: 2, ParentID: 1, TaskWeight: 2},
+		{ID: 3, ParentID: 2, TaskWeight: 3},
+		{ID: 4, ParentID: 2, TaskWeight: 5},
+		{ID: 5, ParentID: 1, TaskWeight: 8},
+		{ID: 6, TaskWeight: 13},
+	}
+	children := childrenOf(tasks)
+	for _, tt := range []struct{ id, want int }{{1, 19}, {2, 10}, {3, 3}, {6, 13}} {
+		if got := rollupWeight(tasks[tt.id-1], children); got != tt.want {
+			t.Errorf("rollupWeight(#%d) = %d, want %d", tt.id, got, tt.want)
+		}
+	}
+}
+
+func TestAddInheritsParentEpic(t *testing.T) {
+	setupEpics(t, []Task{
+		{ID: 1, Title: "parent", Status: "todo", EpicID: 1},
+		{ID: 2, Title: "child", Status: "todo", ParentID: 1},
+	}, Epic{ID: 1, Title: "e1"}, Epic{ID: 2, Title: "e2"})
+
+	// 孫タスクも親をたどってエピックを引き継ぐ
+	mustRun(t, "add", "grandchild", "--parent", "2")
+	if got := getTask(t, 3); got.EpicID != 1 || got.ParentID != 2 {
+		t.Errorf("#3 = epic %d, parent %d, want epic 1, parent 2", got.EpicID, got.ParentID)
+	}
+	// 親と同じエピックの指定は受け付ける
+	mustRun(t, "add", "same", "--parent", "1", "--epic", "1")
+
+	res := run(t, "add", "other", "--parent", "2", "--epic", "2")
+	if res.Err == nil {
+		t.Error("親と異なるエピックを指定しても追加できました")
+	}
+	if n := len(loadTasks(t)); n != 4 {
+		t.Errorf("タスク数 = %d, want 4", n)
+	}
+	if res := run(t, "add", "x", "--epic", "9"); exitCode(res.Err) != exitNotFound {
+		t.Errorf("存在しないエピックの終了コード = %d, want %d", exitCode(res.Err), exitNotFound)
+	}
+	if res := run(t, "add", "x", "--parent", "9"); exitCode(res.Err) != exitNotFound {
+		t.Errorf("存在しない親タスクの終了コード = %d, want %d", exitCode(res.Err), exitNotFound)
+	}
+}
+
+func TestLinkEpic(t *testing.T) {
+	setupEpics(t, []Task{
+		{ID: 1, Title: "parent", Status: "todo"},
+		{ID: 2, Title: "child", Status: "todo", ParentID: 1, TaskWeight: 3},
+	}, Epic{ID: 1, Title: "e1"})
+
+	if res := run(t, "epic", "link", "1", "2"); res.Err == nil {
+		t.Error("子タスクをエピックに紐づけられました")
+	}
+	if got := getTask(t, 2).EpicID; got != 0 {
+		t.Errorf("#2 のエピック = %d, want 0", got)
+	}
+
+	mustRun(t, "epic", "link", "1", "1")
+	if got := getTask(t, 1).EpicID; got != 1 {
+		t.Errorf("#1 のエピック = %d, want 1", got)
+	}
+	// 子タスクは親のエピックとして集計される
+	if _, total := epicProgress(loadTasks(t), ""); total[1] != 3 {
+		t.Errorf("エピック 1 の合計ウェイト = %d, want 3", total[1])
+	}
+
+	mustRun(t, "epic", "link", "0", "1")
+	if got := getTask(t, 1).EpicID; got != 0 {
+		t.Errorf("紐づけを外した #1 のエピック = %d, want 0", got)
+	}
+	if res := run(t, "epic", "link", "9", "1"); exitCode(res.Err) != exitNotFound {
+		t.Errorf("存在しないエピックの終了コード = %d, want %d", exitCode(res.Err), exitNotFound)
+	}
+}
@@ -0,0 +1,322 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"golang.org/x/term"
+)
+
+// EstimationScale は見積もりに使えるタスクウェイトの一覧です。
+// todo_config.json の "estimation" で変更できます。Values を省略すると Name のプリセットを使います。
+type EstimationScale struct {
+	Name   string       `json:"name"` // "fibonacci" / "tshirt" / "pow2"、または任意の名前
+	Values []ScaleValue `json:"values,omitempty"`
+}
+
+// ScaleValue はスケール上の1つの値です。Label は入力・表示用（T シャツサイズなど）、Points は TaskWeight に保存する値です。
+type ScaleValue struct {
+	Label  string `json:"label"`
+	Points int    `json:"points"`
+}
+
+var estimationPresets = map[string][]ScaleValue{
+	"fibonacci": {{"1", 1}, {"2", 2}, {"3", 3}, {"5", 5}, {"8", 8}, {"13", 13}, {"21", 21}},
+	"tshirt":    {{"XS", 1}, {"S", 2}, {"M", 3}, {"L", 5}, {"XL", 8}, {"XXL", 13}},
+	"pow2":      {{"1", 1}, {"2", 2}, {"4", 4}, {"8", 8}, {"16", 16}, {"32", 32}},
+}
+
+func defaultEstimation() *EstimationScale {
+	return &EstimationScale{Name: "fibonacci", Values: estimationPresets["fibonacci"]}
+}
+
+// estimation は現在有効な見積もりスケールです。main で設定ファイルの内容に置き換えられます。
+var estimation = defaultEstimation()
+
+// validate は設定ファイルから読み込んだスケールを確認し、Values が省略されていればプリセットで補います。
+func (s *EstimationScale) validate() error {
+	if len(s.Values) == 0 {
+		preset, ok := estimationPresets[s.Name]
+		if !ok {
+			return fmt.Errorf("estimation.name が不明です: %q（fibonacci / tshirt / pow2 または values を指定してください）", s.Name)
+		}
+		s.Values = preset
+		return nil
+	}
+	seen := make(map[string]bool)
+	for _, v := range s.Values {
+		if v.Points <= 0 {
+			return fmt.Errorf("estimation.values のポイントは正の数値で指定してください: %q", v.Label)
+		}
+		key := strings.ToUpper(v.Label)
+		if key == "" || seen[key] {
+			return fmt.Errorf("estimation.values のラベルが空か重複しています: %q", v.Label)
+		}
+		seen[key] = true
+	}
+	sort.SliceStable(s.Values, func(i, j int) bool { return s.Values[i].Points < s.Values[j].Points })
+	return nil
+}
+
+// Allows は points がスケール上の値かを返します。
+func (s *EstimationScale) Allows(points int) bool {
+	for _, v := range s.Values {
+		if v.Points == points {
+			return true
+		}
+	}
+	return false
+}
+
+// Parse はラベル（大文字小文字を区別しない）またはポイントの数値をポイントに変換します。
+func (s *EstimationScale) Parse(value string) (int, error) {
+	for _, v := range s.Values {
+		if strings.EqualFold(v.Label, value) {
+			return v.Points, nil
+		}
+	}
+	if n, err := strconv.Atoi(value); err == nil && s.Allows(n) {
+		return n, nil
+	}
+	return 0, fmt.Errorf("見積もりは %s のいずれかで指定してください: %q", s, value)
+}
+
+// Label は points に対応するラベルを返します（スケールにない場合は数値）。
+func (s *EstimationScale) Label(points int) string {
+	for _, v := range s.Values {
+		if v.Points == points {
+			return v.Label
+		}
+	}
+	return strconv.Itoa(points)
+}
+
+// String は "XS(1) S(2) M(3)" または "1 2 3 5" の形式で値の一覧を返します。
+func (s *EstimationScale) String() string {
+	parts := make([]string, 0, len(s.Values))
+	for _, v := range s.Values {
+		if v.Label == strconv.Itoa(v.Points) {
+			parts = append(parts, v.Label)
+		} else {
+			parts = append(parts, fmt.Sprintf("%s(%d)", v.Label, v.Points))
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+// parseWeight は add / estimate のウェイト引数を解釈します。0 は未見積もりとして受け付けます。
+func parseWeight(value string) (int, error) {
+	if value == "0" {
+		return 0, nil
+	}
+	return estimation.Parse(value)
+}
+
+// EstimateTask はタスクの見積もり（TaskWeight）を更新します。
+func EstimateTask(id int, points int) error {
+	return setEstimate(id, points, "estimate")
+}
+
+func setEstimate(id int, points int, action string) error {
+	unlock, err := store.Lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	task, err := findTask(id)
+	if err != nil {
+		return err
+	}
+	if task.TaskWeight == points {
+		output.Printf("タスク #%d の見積もりは既に %s です", id, estimation.Label(points))
+		return nil
+	}
+
+	if err := checkWeightCapacity(task, points); err != nil {
+		return err
+	}
+	before := task
+	task.TaskWeight = points
+	if err := store.Update(task); err != nil {
+		return err
+	}
+	recordEvent(task, action, "task_weight", strconv.Itoa(before.TaskWeight), strconv.Itoa(points))
+	recordTaskOp(fmt.Sprintf("%s #%d", action, id), id, &before, &task)
+	if points == 0 {
+		output.Printf("タスク #%d を未見積もりに戻しました", id)
+		return nil
+	}
+	output.Printf("タスク #%d の見積もりを %s（%dpt）にしました", id, estimation.Label(points), points)
+	return nil
+}
+
+// checkWeightCapacity はタスクのウェイトを points にしたときに、スプリントのキャパシティを超えないか確認します。
+func checkWeightCapacity(task Task, points int) error {
+	if task.SprintNumber == 0 {
+		return nil
+	}
+	tasks, err := store.List()
+	if err != nil {
+		return err
+	}
+	for i := range tasks {
+		if tasks[i].ID == task.ID {
+			tasks[i].TaskWeight = points
+		}
+	}
+	violations, err := capacityViolations(tasks, task.SprintNumber, task.Assignees.names(), true)
+	if err != nil {
+		return err
+	}
+	return checkOverCapacity(violations)
+}
+
+// ==== プランニングポーカー ===================================================
+
+// pokerAbstain は投票を棄権するときの入力です。
+const pokerAbstain = "?"
+
+// pokerSession は1つのタスクに対するプランニングポーカーの進行状況です。
+// 全員が投票するまで票は公開されません。
+type pokerSession struct {
+	TaskID       int
+	Participants []string
+	votes        map[string]int // 参加者 → ポイント（棄権は 0）
+}
+
+func newPokerSession(taskID int, participants []string) *pokerSession {
+	return &pokerSession{TaskID: taskID, Participants: participants, votes: make(map[string]int)}
+}
+
+// Next は次に投票する参加者を返します。全員投票済みなら空文字です。
+func (p *pokerSession) Next() string {
+	for _, name := range p.Participants {
+		if _, ok := p.votes[name]; !ok {
+			return name
+		}
+	}
+	return ""
+}
+
+// Vote は次の参加者の票を記録します。
+func (p *pokerSession) Vote(value string) error {
+	name := p.Next()
+	if name == "" {
+		return fmt.Errorf("全員が投票済みです")
+	}
+	if value == pokerAbstain {
+		p.votes[name] = 0
+		return nil
+	}
+	points, err := estimation.Parse(value)
+	if err != nil {
+		return err
+	}
+	p.votes[name] = points
+	return nil
+}
+
+// Revote は票を破棄してやり直します。
+func (p *pokerSession) Revote() {
+	p.votes = make(map[string]int)
+}
+
+// Reveal は票を公開し、全員（棄権を除く）が同じ値なら合意した値を返します。
+func (p *pokerSession) Reveal() (int, bool) {
+	output.Printf("=== タスク #%d の見積もり ===", p.TaskID)
+	agreed, consensus := 0, true
+	for _, name := range p.Participants {
+		v := p.votes[name]
+		if v == 0 {
+			output.Printf("  %s %s", padRight(name, 10), pokerAbstain)
+			continue
+		}
+		output.Printf("  %s %s", padRight(name, 10), estimation.Label(v))
+		if agreed != 0 && agreed != v {
+			consensus = false
+		}
+		agreed = v
+	}
+	if agreed == 0 {
+		return 0, false // 全員棄権
+	}
+	return agreed, consensus
+}
+
+// terminalStdin は端末から読み込む元の stdin です。テストで stdin を置き換えた場合は使いません。
+var terminalStdin = stdin
+
+// readVote は票を1行読み込みます。端末から読み込む場合は入力した票を画面に表示しません。
+// 入力が終わった場合は ok が false になります。
+func readVote(sc *bufio.Scanner) (vote string, ok bool) {
+	if fd := int(os.Stdin.Fd()); sc == terminalStdin && term.IsTerminal(fd) {
+		b, err := term.ReadPassword(fd)
+		fmt.Println()
+		return string(b), err == nil
+	}
+	if !sc.Scan() {
+		return "", false
+	}
+	return sc.Text(), true
+}
+
+// runPokerConsole はスプリントコンソールでプランニングポーカーを進行します。
+// 票は画面に表示せずに読み込み、全員の投票が終わってから公開します。
+// 投票や合意した値の入力が終わった場合（EOF）は見積もりを変更せずにエラーを返します。
+func runPokerConsole(sc *bufio.Scanner, taskID int, participants []string) error {
+	task, err := findTask(taskID)
+	if err != nil {
+		return err
+	}
+	output.Printf("プランニングポーカー: #%d %s", task.ID, task.Title)
+	output.Printf("スケール: %s（%s で棄権）", estimation, pokerAbstain)
+
+	session := newPokerSession(taskID, participants)
+	for {
+		for name := session.Next(); name != ""; name = session.Next() {
+			fmt.Printf("%s の見積もり > ", name)
+			vote, ok := readVote(sc)
+			if !ok {
+				return usageErrorf("%s の見積もりを入力できませんでした", name)
+			}
+			if err := session.Vote(strings.TrimSpace(vote)); err != nil {
+				output.Println(err)
+				continue
+			}
+			output.Printf("%s が投票しました", name)
+		}
+
+		agreed, consensus := session.Reveal()
+		if consensus {
+			return setEstimate(taskID, agreed, "poker")
+		}
+
+	agree:
+		for {
+			fmt.Print("合意した値を入力してください（revote: 再投票, cancel: 中止） > ")
+			if !sc.Scan() {
+				return usageErrorf("合意した値を入力できませんでした（中止する場合は cancel を入力してください）")
+			}
+			switch answer := strings.TrimSpace(sc.Text()); answer {
+			case "revote":
+				session.Revote()
+				break agree
+			case "cancel":
+				output.Println("見積もりを中止しました")
+				return nil
+			default:
+				points, err := estimation.Parse(answer)
+				if err != nil {
+					output.Println(err)
+					continue
+				}
+				return setEstimate(taskID, points, "poker")
+			}
+		}
+	}
+}
@@ -0,0 +1,164 @@
+package main
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// useScale は見積もりスケールをテストの間だけ scale に置き換えます。
+func useScale(t *testing.T, scale *EstimationScale) {
+	t.Helper()
+	if err := scale.validate(); err != nil {
+		t.Fatal(err)
+	}
+	saved := estimation
+	estimation = scale
+	t.Cleanup(func() { estimation = saved })
+}
+
+func TestEstimationScaleValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		scale   EstimationScale
+		wantErr bool
+		want    []ScaleValue
+	}{
+		{name: "プリセット", scale: EstimationScale{Name: "tshirt"}, want: estimationPresets["tshirt"]},
+		{name: "不明なプリセット", scale: EstimationScale{Name: "prime"}, wantErr: true},
+		{
+			name:  "独自のスケールはポイント順に並べる",
+			scale: EstimationScale{Name: "team", Values: []ScaleValue{{"big", 10}, {"small", 1}, {"mid", 4}}},
+			want:  []ScaleValue{{"small", 1}, {"mid", 4}, {"big", 10}},
+		},
+		{name: "ポイントが0", scale: EstimationScale{Values: []ScaleValue{{"zero", 0}}}, wantErr: true},
+		{name: "空のラベル", scale: EstimationScale{Values: []ScaleValue{{"", 1}}}, wantErr: true},
+		{name: "大文字小文字だけ違うラベル", scale: EstimationScale{Values: []ScaleValue{{"s", 1}, {"S", 2}}}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.scale.validate()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("validate() の err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && !reflect.DeepEqual(tt.scale.Values, tt.want) {
+				t.Errorf("Values = %v, want %v", tt.scale.Values, tt.want)
+			}
+		})
+	}
+}
+
+func TestEstimationScaleParse(t *testing.T) {
+	tests := []struct {
+		scale   string
+		value   string
+		want    int
+		wantErr bool
+	}{
+		{"fibonacci", "5", 5, false},
+		{"fibonacci", "4", 0, true},
+		{"fibonacci", "M", 0, true},
+		{"tshirt", "m", 3, false},
+		{"tshirt", "XL", 8, false},
+		{"tshirt", "5", 5, false}, // ポイントの数値でも指定できる
+		{"tshirt", "4", 0, true},
+		{"pow2", "16", 16, false},
+		{"pow2", "3", 0, true},
+	}
+	for _, tt := range tests {
+		scale := &EstimationScale{Name: tt.scale}
+		if err := scale.validate(); err != nil {
+			t.Fatal(err)
+		}
+		got, err := scale.Parse(tt.value)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("%s の Parse(%q) = %d, %v, want %d, wantErr %v", tt.scale, tt.value, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestPokerSessionReveal(t *testing.T) {
+	tests := []struct {
+		name          string
+		votes         []string
+		want          int
+		wantConsensus bool
+	}{
+		{"全員一致", []string{"M", "m", "3"}, 3, true},
+		{"割れた", []string{"S", "M", "M"}, 3, false},
+		{"棄権は数えない", []string{"L", "?", "L"}, 5, true},
+		{"棄権と割れた票", []string{"L", "?", "XL"}, 8, false},
+		{"全員棄権", []string{"?", "?", "?"}, 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setupStore(t)
+			useScale(t, &EstimationScale{Name: "tshirt"})
+			session := newPokerSession(1, []string{"taro", "hanako", "jiro"})
+			for _, v := range tt.votes {
+				if err := session.Vote(v); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if got, consensus := session.Reveal(); consensus != tt.wantConsensus || (consensus && got != tt.want) {
+				t.Errorf("Reveal() = %d, %v, want %d, %v", got, consensus, tt.want, tt.wantConsensus)
+			}
+		})
+	}
+}
+
+func TestPokerSessionVote(t *testing.T) {
+	setupStore(t)
+	session := newPokerSession(1, []string{"taro", "hanako"})
+	if err := session.Vote("4"); err == nil {
+		t.Error("スケールにない票がエラーになりません")
+	}
+	if got := session.Next(); got != "taro" {
+		t.Errorf("不正な票の後の Next() = %q, want taro", got)
+	}
+	for _, v := range []string{"3", "5"} {
+		if err := session.Vote(v); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := session.Next(); got != "" {
+		t.Errorf("全員投票後の Next() = %q, want 空", got)
+	}
+	if err := session.Vote("3"); err == nil {
+		t.Error("全員投票後の投票がエラーになりません")
+	}
+	session.Revote()
+	if got := session.Next(); got != "taro" {
+		t.Errorf("Revote() 後の Next() = %q, want taro", got)
+	}
+}
+
+func TestPokerConsole(t *testing.T) {
+	tests := []struct {
+		name       string
+		input      string
+		wantWeight int
+		wantUsage  bool
+	}{
+		{"全員一致", "5\n5\n", 5, false},
+		{"割れたら合意した値を入力", "3\n8\n5\n", 5, false},
+		{"再投票", "3\n8\nrevote\n8\n8\n", 8, false},
+		{"中止", "3\n8\ncancel\n", 0, false},
+		{"投票中に入力が終わる", "5\n", 0, true},
+		{"合意の入力中に入力が終わる", "3\n8\n", 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setupStore(t, Task{ID: 1, Title: "a", Status: "todo"})
+			setInput(t, tt.input)
+			res := run(t, "poker", "1", "taro", "hanako")
+			var usage *usageError
+			if got := errors.As(res.Err, &usage); got != tt.wantUsage {
+				t.Errorf("err = %v, want usageError %v", res.Err, tt.wantUsage)
+			}
+			if got := getTask(t, 1).TaskWeight; got != tt.wantWeight {
+				t.Errorf("ウェイト = %d, want %d", got, tt.wantWeight)
+			}
+		})
+	}
+}
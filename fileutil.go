@@ -0,0 +1,74 @@
+package main
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// newFileMode は writeFileAtomic で新しく作るファイルのパーミッションです。
+const newFileMode = 0o644
+
+// writeFileAtomic は一時ファイルに書き込んで fsync した後に rename で置き換えます。
+// 書き込み途中でクラッシュしても元のファイルが壊れることはありません。
+// 既存のファイルのパーミッションは引き継ぎます（チームで共有しているファイルが本人専用にならないように）。
+func writeFileAtomic(path string, write func(w io.Writer) error) error {
+	mode := os.FileMode(newFileMode)
+	if info, err := os.Stat(path); err == nil {
+		mode = info.Mode().Perm()
+	} else if !os.IsNotExist(err) {
+		return err
+	}
+
+	dir := filepath.Dir(path)
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	tmpName := tmp.Name()
+	defer os.Remove(tmpName) // rename 成功後は何もしない
+
+	// CreateTemp は 0600 で作成するため、置き換える前に元のパーミッションに合わせる
+	if err := tmp.Chmod(mode); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := write(tmp); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmpName, path); err != nil {
+		return err
+	}
+
+	// rename 自体を永続化するためディレクトリも fsync（非対応環境では無視）
+	if d, err := os.Open(dir); err == nil {
+		d.Sync()
+		d.Close()
+	}
+	return nil
+}
+
+// lockPath の排他ロックを取得し、解放用の関数を返します。
+// 他のプロセスがロック中の場合は解放されるまで待ちます。
+func acquireLock(lockPath string) (func(), error) {
+	f, err := os.OpenFile(lockPath, os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	if err := lockFile(f); err != nil {
+		f.Close()
+		return nil, err
+	}
+	return func() {
+		unlockFile(f)
+		f.Close()
+	}, nil
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+)
+
+func TestWriteFileAtomicKeepsMode(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Windows ではパーミッションを確認しない")
+	}
+	dir := t.TempDir()
+	write := func(path string) {
+		t.Helper()
+		if err := writeFileAtomic(path, func(w io.Writer) error {
+			_, err := fmt.Fprintln(w, "[]")
+			return err
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	mode := func(path string) os.FileMode {
+		t.Helper()
+		info, err := os.Stat(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return info.Mode().Perm()
+	}
+
+	shared := filepath.Join(dir, "shared.json")
+	if err := os.WriteFile(shared, []byte("[]\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chmod(shared, 0o664); err != nil { // umask の影響を受けないよう明示的に設定
+		t.Fatal(err)
+	}
+	write(shared)
+	if got := mode(shared); got != 0o664 {
+		t.Errorf("既存のファイルのパーミッション = %o, want 664", got)
+	}
+
+	created := filepath.Join(dir, "new.json")
+	write(created)
+	if got := mode(created); got != newFileMode {
+		t.Errorf("新しいファイルのパーミッション = %o, want %o", got, newFileMode)
+	}
+}
+
+func TestConcurrentWriterWaitsForLock(t *testing.T) {
+	setupStore(t)
+	unlock, err := store.Lock()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 別の端末で実行中のコマンドがロックを持っている間、add は保存せずに待つ
+	done := make(chan *Result)
+	go func() { done <- execute([]string{"add", "a"}, frontendCLI, nil) }()
+	select {
+	case res := <-done:
+		unlock()
+		t.Fatalf("ロック中に add が終了しました: %v", res.Err)
+	case <-time.After(200 * time.Millisecond):
+	}
+	if got := len(loadTasks(t)); got != 0 {
+		t.Fatalf("ロック中に保存されたタスク数 = %d, want 0", got)
+	}
+
+	unlock()
+	select {
+	case res := <-done:
+		if res.Err != nil {
+			t.Fatalf("add: %v", res.Err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("ロックを解放しても add が終了しません")
+	}
+	if got := getTask(t, 1).Title; got != "a" {
+		t.Errorf("#1 = %q, want a", got)
+	}
+}
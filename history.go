@@ -0,0 +1,173 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"strconv"
+	"time"
+)
+
+// historyFile は変更履歴のパスです（todo.json → todo.json.history.jsonl）。
+func historyFile() string {
+	return sidecarPath(store.Path(), sidecarHistory)
+}
+
+// Event はタスクに対する1回の変更を表します。historyFile() に1行ずつ追記されます。
+type Event struct {
+	Time   time.Time `json:"time"`
+	Actor  string    `json:"actor"`
+	TaskID int       `json:"task_id"`
+	Sprint int       `json:"sprint,omitempty"`
+	Action string    `json:"action"` // add, assign, move, delete, doctor
+	Field  string    `json:"field,omitempty"`
+	Old    string    `json:"old,omitempty"`
+	New    string    `json:"new,omitempty"`
+}
+
+// actor は変更を行った人です。--actor オプション、なければ $USER が使われます。
+var actor = defaultActor()
+
+func defaultActor() string {
+	for _, key := range []string{"USER", "USERNAME"} {
+		if v := os.Getenv(key); v != "" {
+			return v
+		}
+	}
+	return "unknown"
+}
+
+// recordEvent は変更履歴を追記します。
+// 変更自体は保存済みのため、履歴の書き込みに失敗しても警告のみ表示します。
+func recordEvent(task Task, action, field, oldValue, newValue string) {
+	ev := Event{
+		Time:   time.Now(),
+		Actor:  actor,
+		TaskID: task.ID,
+		Sprint: task.SprintNumber,
+		Action: action,
+		Field:  field,
+		Old:    oldValue,
+		New:    newValue,
+	}
+	if err := appendEvent(ev); err != nil {
+		output.Warnf("履歴の記録に失敗しました: %v", err)
+	}
+}
+
+func appendEvent(ev Event) error {
+	line, err := json.Marshal(ev)
+	if err != nil {
+		return err
+	}
+	file, err := os.OpenFile(historyFile(), os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := file.Write(append(line, '\n')); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// taskSnapshot は削除されたタスクを復元できるよう JSON 文字列にします。
+func taskSnapshot(task Task) string {
+	data, err := json.Marshal(task)
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
+
+func loadEvents() ([]Event, error) {
+	file, err := os.Open(historyFile())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []Event{}, nil
+		}
+		return nil, err
+	}
+	defer file.Close()
+
+	events := []Event{}
+	sc := bufio.NewScanner(file)
+	sc.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for sc.Scan() {
+		if len(sc.Bytes()) == 0 {
+			continue
+		}
+		var ev Event
+		if err := json.Unmarshal(sc.Bytes(), &ev); err != nil {
+			return nil, err
+		}
+		events = append(events, ev)
+	}
+	return events, sc.Err()
+}
+
+// EventFilter は log コマンドの絞り込み条件です。ゼロ値の項目は条件に含めません。
+type EventFilter struct {
+	TaskID int
+	Sprint int
+	Person string
+	Since  time.Time
+	Until  time.Time // この時刻より前のイベントのみ
+}
+
+func (f EventFilter) match(ev Event) bool {
+	if f.TaskID != 0 && ev.TaskID != f.TaskID {
+		return false
+	}
+	if f.Sprint != 0 && ev.Sprint != f.Sprint {
+		return false
+	}
+	if f.Person != "" && ev.Actor != f.Person {
+		return false
+	}
+	if !f.Since.IsZero() && ev.Time.Before(f.Since) {
+		return false
+	}
+	if !f.Until.IsZero() && !ev.Time.Before(f.Until) {
+		return false
+	}
+	return true
+}
+
+// ShowHistory は1つのタスクの変更履歴を表示します。
+func ShowHistory(id int) error {
+	return ShowLog(EventFilter{TaskID: id})
+}
+
+// ShowLog は条件に合う変更履歴を表示します。
+func ShowLog(filter EventFilter) error {
+	events, err := loadEvents()
+	if err != nil {
+		return err
+	}
+
+	table := &Table{Header: []string{"Time", "Actor", "Task", "Sprint", "Action", "Field", "Old", "New"}}
+	count := 0
+	for _, ev := range events {
+		if !filter.match(ev) {
+			continue
+		}
+		table.Append([]string{
+			ev.Time.Local().Format("2006-01-02 15:04:05"),
+			ev.Actor,
+			strconv.Itoa(ev.TaskID),
+			strconv.Itoa(ev.Sprint),
+			ev.Action,
+			ev.Field,
+			ev.Old,
+			ev.New,
+		})
+		count++
+	}
+	if count == 0 {
+		output.Println("該当する履歴はありません。")
+		return nil
+	}
+	output.AddTable(table)
+	return nil
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// logRows は history / log の表を「タスク:操作した人:フィールド」の一覧にします。
+func logRows(t *testing.T, res *Result) []string {
+	t.Helper()
+	rows := []string{}
+	for _, e := range res.Entries {
+		if e.Kind != EntryTable {
+			continue
+		}
+		for _, row := range e.Table.Rows {
+			rows = append(rows, row[2]+":"+row[1]+":"+row[5])
+		}
+	}
+	return rows
+}
+
+// setupEvents は日時の決まった変更履歴を書き込みます。
+func setupEvents(t *testing.T) {
+	t.Helper()
+	setupStore(t)
+	day := func(d int) time.Time { return time.Date(2024, 5, d, 12, 0, 0, 0, time.Local) }
+	for _, ev := range []Event{
+		{Time: day(1), Actor: "alice", TaskID: 1, Action: "add", Field: "title", New: "a"},
+		{Time: day(2), Actor: "bob", TaskID: 2, Sprint: 1, Action: "add", Field: "title", New: "b"},
+		{Time: day(3), Actor: "alice", TaskID: 1, Sprint: 1, Action: "plan", Field: "sprint_number", Old: "0", New: "1"},
+		{Time: day(4), Actor: "bob", TaskID: 1, Sprint: 1, Action: "move", Field: "status", Old: "todo", New: "doing"},
+		{Time: day(5), Actor: "alice", TaskID: 2, Sprint: 2, Action: "complete", Field: "status", Old: "doing", New: "done"},
+	} {
+		if err := appendEvent(ev); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestLogFilters(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		want []string
+	}{
+		{"条件なし", []string{"log"}, []string{"1:alice:title", "2:bob:title", "1:alice:sprint_number", "1:bob:status", "2:alice:status"}},
+		{"タスク", []string{"history", "1"}, []string{"1:alice:title", "1:alice:sprint_number", "1:bob:status"}},
+		{"スプリント", []string{"log", "--sprint", "1"}, []string{"2:bob:title", "1:alice:sprint_number", "1:bob:status"}},
+		{"操作した人", []string{"log", "--person", "bob"}, []string{"2:bob:title", "1:bob:status"}},
+		{"開始日", []string{"log", "--since", "2024-05-04"}, []string{"1:bob:status", "2:alice:status"}},
+		{"終了日（当日を含む）", []string{"log", "--until", "2024-05-02"}, []string{"1:alice:title", "2:bob:title"}},
+		{"組み合わせ", []string{"log", "--person", "alice", "--since", "2024-05-02", "--until", "2024-05-04"}, []string{"1:alice:sprint_number"}},
+		{"該当なし", []string{"log", "--person", "carol"}, []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setupEvents(t)
+			res := mustRun(t, tt.args...)
+			if got := logRows(t, res); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("%v = %q, want %q", tt.args, got, tt.want)
+			}
+			if len(tt.want) == 0 && !strings.Contains(res.String(), "該当する履歴はありません") {
+				t.Errorf("該当なしのメッセージが表示されません:\n%s", res)
+			}
+		})
+	}
+}
+
+func TestLogRejectsInvalidArgs(t *testing.T) {
+	for _, args := range [][]string{
+		{"history", "abc"},
+		{"history", "0"},
+		{"log", "--since", "2024/05/01"},
+		{"log", "--until", "yesterday"},
+		{"log", "--sprint", "x"},
+	} {
+		setupEvents(t)
+		if res := run(t, args...); exitCode(res.Err) != exitUsage {
+			t.Errorf("%v の終了コード = %d, want %d（err: %v）", args, exitCode(res.Err), exitUsage, res.Err)
+		}
+	}
+}
+
+func TestCommandsRecordEvents(t *testing.T) {
+	setupStore(t)
+	saved := actor
+	actor = "alice"
+	t.Cleanup(func() { actor = saved })
+
+	mustRun(t, "add", "a")
+	actor = "bob"
+	mustRun(t, "move", "1", "doing")
+
+	events, err := loadEvents()
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := []string{}
+	for _, ev := range events {
+		got = append(got, strings.Join([]string{ev.Actor, ev.Action, ev.Field, ev.Old, ev.New}, ":"))
+	}
+	want := []string{"alice:add:title::a", "bob:move:status:todo:doing"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("履歴 = %q, want %q", got, want)
+	}
+}
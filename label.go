@@ -0,0 +1,184 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"gonum.org/v1/plot"
+	"gonum.org/v1/plot/plotter"
+	"gonum.org/v1/plot/vg"
+)
+
+// applyLabelArgs は label コマンドの引数をラベル一覧に適用します。
+//
+//	name / +name   ラベルを追加
+//	-name          ラベルを削除
+func applyLabelArgs(labels []string, args []string) ([]string, error) {
+	set := make(map[string]bool, len(labels))
+	for _, l := range labels {
+		set[l] = true
+	}
+	for _, arg := range args {
+		remove := strings.HasPrefix(arg, "-")
+		name := strings.TrimPrefix(strings.TrimPrefix(arg, "-"), "+")
+		if name == "" || strings.ContainsAny(name, ", ") {
+			return nil, fmt.Errorf("ラベル名が不正です: %q", arg)
+		}
+		if err := checkText("ラベル名", name); err != nil {
+			return nil, err
+		}
+		if remove {
+			delete(set, name)
+		} else {
+			set[name] = true
+		}
+	}
+	result := make([]string, 0, len(set))
+	for l := range set {
+		result = append(result, l)
+	}
+	sort.Strings(result)
+	return result, nil
+}
+
+// LabelTask はタスクのラベルを追加・削除します。
+func LabelTask(id int, args []string) error {
+	unlock, err := store.Lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	task, err := findTask(id)
+	if err != nil {
+		return err
+	}
+	labels, err := applyLabelArgs(task.Labels, args)
+	if err != nil {
+		return err
+	}
+
+	before := task
+	task.Labels = labels
+	if err := store.Update(task); err != nil {
+		return err
+	}
+	recordEvent(task, "label", "labels", strings.Join(before.Labels, ", "), strings.Join(task.Labels, ", "))
+	recordTaskOp(fmt.Sprintf("label #%d", id), id, &before, &task)
+	output.Printf("タスク #%d のラベル: %s", id, formatLabels(task.Labels))
+	return nil
+}
+
+func (t Task) HasLabel(label string) bool {
+	for _, l := range t.Labels {
+		if l == label {
+			return true
+		}
+	}
+	return false
+}
+
+// filterByLabel は label が付いたタスクだけを返します。label が空の場合はすべて返します。
+func filterByLabel(tasks []Task, label string) []Task {
+	if label == "" {
+		return tasks
+	}
+	filtered := []Task{}
+	for _, t := range tasks {
+		if t.HasLabel(label) {
+			filtered = append(filtered, t)
+		}
+	}
+	return filtered
+}
+
+// useColor は端末に色付きで出力するかを返します（NO_COLOR が設定されているかパイプ出力の場合は無効）。
+func useColor() bool {
+	if os.Getenv("NO_COLOR") != "" {
+		return false
+	}
+	info, err := os.Stdout.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// colorLabel はラベル名を ColorFromName の色で着色します（グラフと同じ色）。
+func colorLabel(label string) string {
+	if !useColor() {
+		return label
+	}
+	c := ColorFromName(label)
+	return fmt.Sprintf("\x1b[38;2;%d;%d;%dm%s\x1b[0m", c.R, c.G, c.B, label)
+}
+
+// formatLabels は表示用にラベルを着色して連結します。
+func formatLabels(labels []string) string {
+	parts := make([]string, len(labels))
+	for i, l := range labels {
+		parts[i] = colorLabel(l)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// allLabels はタスクに付いているラベルを名前順に返します。
+func allLabels(tasks []Task) []string {
+	set := make(map[string]bool)
+	for _, t := range tasks {
+		for _, l := range t.Labels {
+			set[l] = true
+		}
+	}
+	labels := make([]string, 0, len(set))
+	for l := range set {
+		labels = append(labels, l)
+	}
+	sort.Strings(labels)
+	return labels
+}
+
+// showLabelProgress はラベルごとの完了ウェイト／合計ウェイトを表示し、label_progress.png に棒グラフを出力します。
+// 棒の色は端末表示と同じく ColorFromName で決まります。
+func showLabelProgress(tasks []Task) error {
+	labels := allLabels(tasks)
+	done := make(map[string]int)
+	total := make(map[string]int)
+	for _, t := range tasks {
+		for _, l := range t.Labels {
+			total[l] += t.TaskWeight
+			if t.IsDone() {
+				done[l] += t.TaskWeight
+			}
+		}
+	}
+
+	output.Println()
+	width := columnWidth("ラベル", labels)
+	output.Printf("%s  完了重み/合計重み\t進捗率", padRight("ラベル", width))
+	output.Println("-------------------------------------")
+	for _, l := range labels {
+		pad := strings.Repeat(" ", width-textWidth(l)) // 色付けのエスケープは幅に含めない
+		output.Printf("%s%s  %d/%d\t\t%d%%", colorLabel(l), pad, done[l], total[l], progressRate(done[l], total[l]))
+	}
+
+	p := plot.New()
+	p.Title.Text = "Progress by Label"
+	p.Y.Label.Text = "Progress (%)"
+	p.NominalX(labels...)
+	for i, l := range labels {
+		vals := make(plotter.Values, len(labels))
+		vals[i] = float64(progressRate(done[l], total[l]))
+		bar, err := plotter.NewBarChart(vals, vg.Points(30))
+		if err != nil {
+			return err
+		}
+		bar.LineStyle.Width = vg.Length(0)
+		bar.Color = ColorFromName(l)
+		p.Add(bar)
+	}
+	p.Y.Max = 100
+	p.X.Padding = vg.Points(40)
+	p.X.Min = -0.5
+	p.X.Max = float64(len(labels)) - 0.5
+	return p.Save(8*vg.Inch, 4*vg.Inch, "label_progress.png")
+}
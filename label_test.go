@@ -0,0 +1,181 @@
+package main
+
+import (
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestApplyLabelArgs(t *testing.T) {
+	tests := []struct {
+		name    string
+		labels  []string
+		args    []string
+		want    []string
+		wantErr bool
+	}{
+		{"追加（名前順）", nil, []string{"ui", "+bug"}, []string{"bug", "ui"}, false},
+		{"削除", []string{"bug", "ui"}, []string{"-bug"}, []string{"ui"}, false},
+		{"付いていないラベルの削除", []string{"ui"}, []string{"-bug"}, []string{"ui"}, false},
+		{"重複", []string{"ui"}, []string{"ui", "+ui"}, []string{"ui"}, false},
+		{"追加と削除", []string{"ui"}, []string{"+bug", "-ui"}, []string{"bug"}, false},
+		{"空の名前", nil, []string{"+"}, nil, true},
+		{"空の名前（削除）", nil, []string{"-"}, nil, true},
+		{"カンマ", nil, []string{"a,b"}, nil, true},
+		{"空白", nil, []string{"a b"}, nil, true},
+		{"制御文字", nil, []string{"a\x1b[31m"}, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := applyLabelArgs(tt.labels, tt.args)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("applyLabelArgs(%q, %q) の err = %v, wantErr %v", tt.labels, tt.args, err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("applyLabelArgs(%q, %q) = %q, want %q", tt.labels, tt.args, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFilterByLabel(t *testing.T) {
+	tasks := []Task{
+		{ID: 1, Labels: []string{"ui"}},
+		{ID: 2, Labels: []string{"bug", "ui"}},
+		{ID: 3},
+	}
+	tests := []struct {
+		label string
+		want  []int
+	}{
+		{"", []int{1, 2, 3}},
+		{"ui", []int{1, 2}},
+		{"bug", []int{2}},
+		{"none", []int{}},
+	}
+	for _, tt := range tests {
+		got := []int{}
+		for _, task := range filterByLabel(tasks, tt.label) {
+			got = append(got, task.ID)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("filterByLabel(%q) = %v, want %v", tt.label, got, tt.want)
+		}
+	}
+}
+
+// labelTasks は --label の絞り込みを確かめるタスクです。#2 は「ui」の付いていない親 #1 からエピック 1 を引き継ぎます。
+var labelTasks = []Task{
+	{ID: 1, Title: "parent", Status: "todo", TaskWeight: 5, SprintNumber: 1, EpicID: 1, Assignees: AssigneeList{{Name: "alice"}}},
+	{ID: 2, Title: "child", Status: "done", TaskWeight: 3, SprintNumber: 1, ParentID: 1, Labels: []string{"ui"}, Assignees: AssigneeList{{Name: "bob"}}},
+	{ID: 3, Title: "other", Status: "doing", TaskWeight: 2, SprintNumber: 1, Labels: []string{"ui", "bug"}, Assignees: AssigneeList{{Name: "bob"}}},
+	{ID: 4, Title: "backlog", Status: "todo", TaskWeight: 1, Labels: []string{"bug"}},
+}
+
+func setupLabels(t *testing.T) {
+	t.Helper()
+	setupSprint(t, labelTasks...)
+	if err := store.SaveEpic(Epic{ID: 1, Title: "e1"}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// hasLine は出力に prefix で始まり want を含む行があるかを返します。
+func hasLine(res *Result, prefix, want string) bool {
+	for _, line := range strings.Split(res.String(), "\n") {
+		if strings.HasPrefix(line, prefix) && strings.Contains(line, want) {
+			return true
+		}
+	}
+	return false
+}
+
+func TestListLabel(t *testing.T) {
+	setupLabels(t)
+	res := mustRun(t, "list", "--label", "ui")
+	got := []string{}
+	for _, row := range res.Entries[0].Table.Rows {
+		got = append(got, row[0]+":"+row[2])
+	}
+	// 絞り込んでもエピックは親から求める
+	if want := []string{"2:1", "3:-"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("list --label ui = %q, want %q", got, want)
+	}
+}
+
+func TestProgressLabel(t *testing.T) {
+	setupLabels(t)
+	res := mustRun(t, "progress", "--label", "ui")
+	if hasLine(res, "alice", "") {
+		t.Errorf("ラベルの付いていないタスクの担当者が表示されました:\n%s", res)
+	}
+	if !hasLine(res, "bob", "3.0/5.0") {
+		t.Errorf("bob の進捗が 3.0/5.0 ではありません:\n%s", res)
+	}
+	// ラベルの付いていない親からエピックを引き継いだ子タスクも、エピックの進捗に含める
+	if !hasLine(res, "#1 e1", "3/3") {
+		t.Errorf("エピック 1 の進捗が 3/3 ではありません:\n%s", res)
+	}
+	if hasLine(res, "ui", "") || fileExists("label_progress.png") {
+		t.Errorf("絞り込み時にラベルごとの進捗が表示されました:\n%s", res)
+	}
+
+	res = mustRun(t, "progress")
+	if !hasLine(res, "#1 e1", "3/8") || !hasLine(res, "ui", "3/5") || !hasLine(res, "bug", "0/3") {
+		t.Errorf("絞り込みなしの進捗が正しくありません:\n%s", res)
+	}
+}
+
+func TestShowLabelProgress(t *testing.T) {
+	setupStore(t)
+	res := capture(nil, func() error { return showLabelProgress(labelTasks) })
+	if res.Err != nil {
+		t.Fatal(res.Err)
+	}
+	if !hasLine(res, "bug", "0/3\t\t0%") || !hasLine(res, "ui", "3/5\t\t60%") {
+		t.Errorf("ラベルごとの進捗が正しくありません:\n%s", res)
+	}
+	if !fileExists("label_progress.png") {
+		t.Error("label_progress.png が出力されません")
+	}
+}
+
+func TestContributionLabel(t *testing.T) {
+	contrib, unfinished := contributionShares(filterByLabel(labelTasks, "ui"))
+	if want := map[string]float64{"bob": 3}; !reflect.DeepEqual(contrib, want) || unfinished != 2 {
+		t.Errorf("contributionShares(ui) = %v, %v, want %v, 2", contrib, unfinished, want)
+	}
+	contrib, unfinished = contributionShares(labelTasks)
+	if want := map[string]float64{"bob": 3}; !reflect.DeepEqual(contrib, want) || unfinished != 8 {
+		t.Errorf("contributionShares = %v, %v, want %v, 8", contrib, unfinished, want)
+	}
+
+	setupLabels(t)
+	mustRun(t, "contribution", "--label", "ui")
+	if !fileExists("contribution.png") {
+		t.Error("contribution.png が出力されません")
+	}
+}
+
+func TestDoingLabel(t *testing.T) {
+	setupLabels(t)
+	res := capture(nil, func() error { return ListDoingTasks(1, "ui") })
+	if res.Err != nil {
+		t.Fatal(res.Err)
+	}
+	got := make(map[string][]string)
+	for _, e := range res.Entries {
+		for _, row := range e.Table.Rows {
+			got[e.Table.Title] = append(got[e.Table.Title], row[0])
+		}
+	}
+	if want := map[string][]string{"doing": {"3"}, "done": {"2"}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("ListDoingTasks(1, ui) = %v, want %v", got, want)
+	}
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
@@ -3,65 +3,74 @@ package main
 import (
 	"fmt"
 	"os"
-	"strconv"
+	"strings"
 )
 
 func main() {
-	if len(os.Args) < 2 {
-		fmt.Println("Usage: todo [add|list|complete|delete] ...")
-		return
+	cfg, err := loadConfig()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "todo_config.json:", err)
+		os.Exit(exitFailure)
 	}
 
-	cmd := os.Args[1]
-
-	switch cmd {
-	case "add":
-		if len(os.Args) < 5 {
-			fmt.Println("Usage: todo add <title> <sprintNumber> <taskWeight>")
-			return
-		}
-		title := os.Args[2]
-		sprintNumber, err1 := strconv.Atoi(os.Args[3])
-		taskWeight, err2 := strconv.Atoi(os.Args[4])
-		if err1 != nil || err2 != nil {
-			fmt.Println("sprintNumberとtaskWeightは数値で指定してください")
-			return
+	// 先頭のグローバルオプション（--store, --data, --actor）は設定ファイルより優先
+	for len(os.Args) >= 3 && strings.HasPrefix(os.Args[1], "--") {
+		switch os.Args[1] {
+		case "--store":
+			cfg.Store = os.Args[2]
+		case "--data":
+			cfg.DataPath = os.Args[2]
+		case "--actor":
+			actor = os.Args[2]
+		default:
+			fmt.Fprintln(os.Stderr, "不明なオプションです:", os.Args[1])
+			os.Exit(exitUsage)
 		}
-		AddTask(title, sprintNumber, taskWeight)
-	case "list":
-		ListTasks()
-	case "assign":
-		id, _ := strconv.Atoi(os.Args[2])
-		name := ""
-		if len(os.Args) >= 4 {
-			name = os.Args[3]
-		} else {
-			fmt.Println("Usage: todo assign <taskID> <name>")
-			return
+		os.Args = append(os.Args[:1], os.Args[3:]...)
+	}
+
+	if cfg.Workflow != nil {
+		if err := cfg.Workflow.validate(); err != nil {
+			fmt.Fprintln(os.Stderr, "todo_config.json:", err)
+			os.Exit(exitFailure)
 		}
-		AssignTask(id, name)
-	case "complete":
-		id, _ := strconv.Atoi(os.Args[2])
-		CompleteTask(id)
-	case "delete":
-		id, _ := strconv.Atoi(os.Args[2])
-		DeleteTask(id)
-	case "timerstart":
-		TimerStartSprint()
-	case "timersetting":
-		if len(os.Args) < 5 {
-			fmt.Println("Usage: todo timersetting <planningTime> <developmentTime> <reviewTime>")
-			return
+		workflow = cfg.Workflow
+	}
+
+	if cfg.Estimation != nil {
+		if err := cfg.Estimation.validate(); err != nil {
+			fmt.Fprintln(os.Stderr, "todo_config.json:", err)
+			os.Exit(exitFailure)
 		}
-		planningTime, _ := strconv.Atoi(os.Args[2])
-		developmentTime, _ := strconv.Atoi(os.Args[3])
-		reviewTime, _ := strconv.Atoi(os.Args[4])
-		TimerSetting(planningTime, developmentTime, reviewTime)
-	case "progress":
-		ShowProgress()
-	case "contribution":
-		ShowContribution()
+		estimation = cfg.Estimation
+	}
+
+	switch cfg.CapacityPolicy {
+	case "":
+	case "warn", "refuse":
+		capacityPolicy = cfg.CapacityPolicy
 	default:
-		fmt.Println("Unknown command:", cmd)
+		fmt.Fprintln(os.Stderr, "todo_config.json: capacity_policy は warn または refuse を指定してください")
+		os.Exit(exitFailure)
+	}
+
+	if err := setupChartFont(cfg.ChartFont); err != nil {
+		fmt.Fprintln(os.Stderr, "todo_config.json: chart_font を読み込めませんでした（埋め込みフォントを使用します）:", err)
+		if err := setupChartFont(""); err != nil {
+			fmt.Fprintln(os.Stderr, "グラフのフォントを読み込めませんでした:", err)
+			os.Exit(exitFailure)
+		}
+	}
+
+	store, err = openStore(cfg)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "保存先を開けませんでした:", err)
+		os.Exit(exitFailure)
+	}
+
+	res := execute(os.Args[1:], frontendCLI, printEntry)
+	if res.Err != nil {
+		printError(os.Stderr, res.Err)
 	}
+	os.Exit(exitCode(res.Err))
 }
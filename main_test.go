@@ -0,0 +1,227 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"flag"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// テスト用の補助関数です。各テストは一時ディレクトリを作業ディレクトリにし、
+// そこにある JSON ファイルを保存先にして実行します（timer_setting.json やグラフ画像も一時ディレクトリに書かれます）。
+
+// setupStore は一時ディレクトリの保存先を用意し、tasks を書き込みます。
+func setupStore(t *testing.T, tasks ...Task) {
+	t.Helper()
+	dir := t.TempDir()
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(wd) })
+
+	saved := store
+	store = &jsonTaskStore{path: filepath.Join(dir, dataFile)}
+	t.Cleanup(func() { store = saved })
+
+	if len(tasks) > 0 {
+		if err := store.ReplaceAll(tasks); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// setInput は対話的な入力（stdin）を input に置き換えます。
+func setInput(t *testing.T, input string) {
+	t.Helper()
+	saved := stdin
+	stdin = bufio.NewScanner(strings.NewReader(input))
+	t.Cleanup(func() { stdin = saved })
+}
+
+// run は CLI と同じようにコマンドを実行します。
+func run(t *testing.T, args ...string) *Result {
+	t.Helper()
+	return execute(args, frontendCLI, nil)
+}
+
+// mustRun はコマンドを実行し、エラーならテストを失敗させます。
+func mustRun(t *testing.T, args ...string) *Result {
+	t.Helper()
+	res := run(t, args...)
+	if res.Err != nil {
+		t.Fatalf("%s: %v\n%s", strings.Join(args, " "), res.Err, res)
+	}
+	return res
+}
+
+// loadTasks は保存されているタスクをすべて返します。
+func loadTasks(t *testing.T) []Task {
+	t.Helper()
+	tasks, err := store.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tasks
+}
+
+// getTask は id のタスクを返します。
+func getTask(t *testing.T, id int) Task {
+	t.Helper()
+	task, err := store.Get(id)
+	if err != nil {
+		t.Fatalf("タスク #%d: %v", id, err)
+	}
+	return task
+}
+
+func TestExitCodes(t *testing.T) {
+	tests := []struct {
+		name       string
+		args       []string
+		wantCode   int
+		wantStderr string
+	}{
+		{"引数の不足", []string{"complete"}, exitUsage, "引数の数が正しくありません\nUsage: todo complete <taskID>\n"},
+		{"数値でない ID", []string{"complete", "abc"}, exitUsage, "タスクIDは正の整数で指定してください: \"abc\"\nUsage: todo complete <taskID>\n"},
+		{"存在しない ID", []string{"complete", "9"}, exitNotFound, "タスク #9 は存在しません\n"},
+		{"存在しない ID（show）", []string{"show", "9"}, exitNotFound, "タスク #9 は存在しません\n"},
+		{"存在しない ID（depend）", []string{"depend", "9", "1"}, exitNotFound, "タスク #9 は存在しません\n"},
+		{"存在しない依存先", []string{"depend", "1", "9"}, exitNotFound, "タスク #9 は存在しません\n"},
+		{"不明なコマンド", []string{"frobnicate"}, exitUsage, "不明なコマンドです: frobnicate（todo help で一覧を表示します）\n"},
+		{"不明なサブコマンド", []string{"sprint", "frob"}, exitUsage, "不明なコマンドです: frob（todo sprint help で一覧を表示します）\n"},
+		{"不明なオプション", []string{"list", "--frob"}, exitUsage, ""},
+		{"成功", []string{"list"}, exitOK, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setupStore(t, Task{ID: 1, Title: "a", Status: "todo", TaskWeight: 1})
+			res := run(t, tt.args...)
+			if got := exitCode(res.Err); got != tt.wantCode {
+				t.Errorf("exitCode = %d, want %d（err: %v）", got, tt.wantCode, res.Err)
+			}
+			if res.Err == nil || tt.wantStderr == "" {
+				return
+			}
+			var stderr bytes.Buffer
+			printError(&stderr, res.Err)
+			if got := stderr.String(); got != tt.wantStderr {
+				t.Errorf("stderr = %q, want %q", got, tt.wantStderr)
+			}
+		})
+	}
+}
+
+func TestParseInterspersed(t *testing.T) {
+	tests := []struct {
+		args []string
+		want []string
+	}{
+		{[]string{"a", "--desc", "d", "b"}, []string{"a", "b"}},
+		{[]string{"--", "-a", "-b"}, []string{"-a", "-b"}},
+		{[]string{"a", "--", "-b", "--desc"}, []string{"a", "-b", "--desc"}},
+		{[]string{"a", "--desc", "d", "--", "-b", "-c"}, []string{"a", "-b", "-c"}},
+	}
+	for _, tt := range tests {
+		fs := flag.NewFlagSet("test", flag.ContinueOnError)
+		desc := fs.String("desc", "", "")
+		got, err := parseInterspersed(fs, tt.args)
+		if err != nil {
+			t.Errorf("parseInterspersed(%q): %v", tt.args, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("parseInterspersed(%q) = %q, want %q", tt.args, got, tt.want)
+		}
+		if strings.Contains(strings.Join(tt.args, " "), "--desc d") && *desc != "d" {
+			t.Errorf("parseInterspersed(%q) の --desc = %q, want d", tt.args, *desc)
+		}
+	}
+}
+
+func TestDoubleDashTerminator(t *testing.T) {
+	setupStore(t)
+	// "--" の後ろは "-" で始まっていても位置引数になる
+	mustRun(t, "add", "--desc", "x", "--", "-a title", "0", "0")
+	mustRun(t, "add", "b")
+	mustRun(t, "describe", "2", "--", "-1")
+	if task := getTask(t, 1); task.Title != "-a title" || task.Description != "x" {
+		t.Errorf("#1 = %q（説明 %q）, want \"-a title\"（説明 \"x\"）", task.Title, task.Description)
+	}
+	if got := getTask(t, 2).Description; got != "-1" {
+		t.Errorf("#2 の説明 = %q, want \"-1\"", got)
+	}
+}
+
+// failingListStore は List だけが失敗する保存先です。
+type failingListStore struct{ TaskStore }
+
+var errDisk = errors.New("disk error")
+
+func (failingListStore) List() ([]Task, error) { return nil, errDisk }
+
+func TestListErrorsAreReturned(t *testing.T) {
+	for _, args := range [][]string{
+		{"list"}, {"backlog"}, {"progress"}, {"cycletime"}, {"sprint", "list"}, {"epic", "list"}, {"migrate"},
+	} {
+		setupStore(t, Task{ID: 1, Title: "a", Status: "todo", TaskWeight: 1})
+		store = failingListStore{store}
+		res := run(t, args...)
+		if !errors.Is(res.Err, errDisk) || exitCode(res.Err) != exitFailure {
+			t.Errorf("%v の err = %v, want %v（終了コード %d）", args, res.Err, errDisk, exitFailure)
+		}
+	}
+}
+
+func TestChartErrorsAreReturned(t *testing.T) {
+	// 出力先と同じ名前のディレクトリがあるとグラフ画像を保存できない
+	for _, tt := range []struct {
+		args []string
+		file string
+	}{
+		{[]string{"progress"}, "progress.png"},
+		{[]string{"progress"}, "label_progress.png"},
+		{[]string{"progress"}, "epic_progress.png"},
+		{[]string{"contribution"}, "contribution.png"},
+		{[]string{"velocity"}, "velocity.png"},
+	} {
+		setupSprint(t, Task{ID: 1, Title: "a", Status: "done", TaskWeight: 1, SprintNumber: 1, EpicID: 1,
+			Labels: []string{"ui"}, Assignees: AssigneeList{{Name: "taro"}}})
+		if err := store.SaveEpic(Epic{ID: 1, Title: "e1"}); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Mkdir(tt.file, 0o755); err != nil {
+			t.Fatal(err)
+		}
+		res := run(t, tt.args...)
+		if res.Err == nil || !strings.Contains(res.Err.Error(), "グラフ画像の保存に失敗しました") || exitCode(res.Err) != exitFailure {
+			t.Errorf("%s を保存できないときの %v の err = %v", tt.file, tt.args, res.Err)
+		}
+	}
+}
+
+func TestCompleteSucceedsWhenUnblockedReportFails(t *testing.T) {
+	// 完了を保存した後の表示に失敗しても、完了自体は成功として扱う
+	setupStore(t, Task{ID: 1, Title: "a", Status: "doing", TaskWeight: 1})
+	saved := store
+	store = failingListStore{store}
+	res := run(t, "complete", "1")
+	store = saved
+	if res.Err != nil {
+		t.Fatalf("complete の err = %v, want nil", res.Err)
+	}
+	if task := getTask(t, 1); !task.IsDone() {
+		t.Errorf("#1 のステータス = %s, want done", task.Status)
+	}
+	if len(res.Entries) == 0 || res.Entries[len(res.Entries)-1].Kind != EntryWarning {
+		t.Errorf("警告が表示されません:\n%s", res)
+	}
+}
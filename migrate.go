@@ -0,0 +1,113 @@
+package main
+
+import (
+	"time"
+)
+
+// MigrateTasks は保存済みのタスクを読み込み直し、最新の形式で書き戻します。
+// 旧形式のデータは読み込み時に変換されるため、書き戻すだけで移行が完了します。
+//   - assignees: 文字列 → 担当者リスト
+//   - done / 担当者の有無 → status
+//   - created_at / started_at / completed_at がないタスクは変更履歴から補完
+//   - タスクが参照しているスプリントの記録がなければ作成
+func MigrateTasks() error {
+	unlock, err := store.Lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	tasks, err := store.List()
+	if err != nil {
+		return err
+	}
+	events, err := loadEvents()
+	if err != nil {
+		return err
+	}
+	filled := backfillTimestamps(tasks, events)
+
+	if err := store.ReplaceAll(tasks); err != nil {
+		return err
+	}
+	created, err := createMissingSprints(tasks)
+	if err != nil {
+		return err
+	}
+	output.Printf("%d 件のタスクを最新の形式に移行しました（日時を補完: %d 件、スプリントを作成: %d 件）。", len(tasks), filled, created)
+	return nil
+}
+
+// backfillTimestamps は日時が記録されていないタスクを変更履歴から補完し、補完した件数を返します。
+// 既に記録されている日時は変更しません。
+func backfillTimestamps(tasks []Task, events []Event) int {
+	filled := 0
+	for i := range tasks {
+		t := &tasks[i]
+		changed := false
+		var lastDone *time.Time
+		for _, ev := range events {
+			if ev.TaskID != t.ID {
+				continue
+			}
+			at := ev.Time
+			switch {
+			case ev.Action == "add" && t.CreatedAt == nil:
+				t.CreatedAt = &at
+				changed = true
+			case ev.Field == "assignees" && ev.New != "" && t.StartedAt == nil:
+				t.StartedAt = &at
+				changed = true
+			case ev.Field == "status" && ev.New == workflow.Start && t.StartedAt == nil:
+				t.StartedAt = &at
+				changed = true
+			case ev.Field == "status" && ev.New == workflow.Done:
+				lastDone = &at // 差し戻し後の再完了を考慮して最後の完了を使う
+			}
+		}
+		if lastDone != nil && t.IsDone() && t.CompletedAt == nil {
+			t.CompletedAt = lastDone
+			changed = true
+		}
+		if changed {
+			filled++
+		}
+	}
+	return filled
+}
+
+// createMissingSprints はタスクが参照しているのに記録がないスプリントを作成し、作成した件数を返します。
+// 現在のスプリント（currentSprint）は active、それより前のスプリントは終了済みとみなして closed、それ以降は planned になります。
+func createMissingSprints(tasks []Task) (int, error) {
+	current, err := currentSprint()
+	if err != nil {
+		return 0, err
+	}
+
+	created := 0
+	seen := make(map[int]bool)
+	for _, t := range tasks {
+		if t.SprintNumber <= 0 || seen[t.SprintNumber] {
+			continue
+		}
+		seen[t.SprintNumber] = true
+		if _, err := store.GetSprint(t.SprintNumber); err == nil {
+			continue
+		} else if err != ErrSprintNotFound {
+			return 0, err
+		}
+
+		sp := Sprint{Number: t.SprintNumber, State: SprintPlanned}
+		switch {
+		case t.SprintNumber == current:
+			sp.State = SprintActive
+		case t.SprintNumber < current:
+			sp.State = SprintClosed
+		}
+		if err := store.SaveSprint(sp); err != nil {
+			return 0, err
+		}
+		created++
+	}
+	return created, nil
+}
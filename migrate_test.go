@@ -0,0 +1,63 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBackfillTimestamps(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2024, 5, d, 12, 0, 0, 0, time.UTC) }
+	recorded := day(20)
+	tasks := []Task{
+		{ID: 1, Title: "割り当てで開始", Status: "done"},
+		{ID: 2, Title: "移動で開始・再完了", Status: "done"},
+		{ID: 3, Title: "差し戻し中", Status: "doing"},
+		{ID: 4, Title: "記録済み", Status: "done", CreatedAt: &recorded, StartedAt: &recorded, CompletedAt: &recorded},
+		{ID: 5, Title: "履歴なし", Status: "done"},
+	}
+	events := []Event{
+		{Time: day(1), TaskID: 1, Action: "add", Field: "title"},
+		{Time: day(2), TaskID: 1, Action: "assign", Field: "assignees", New: "alice"},
+		{Time: day(3), TaskID: 1, Action: "complete", Field: "status", Old: "doing", New: "done"},
+		{Time: day(1), TaskID: 2, Action: "add", Field: "title"},
+		{Time: day(2), TaskID: 2, Action: "move", Field: "status", Old: "todo", New: "doing"},
+		{Time: day(3), TaskID: 2, Action: "move", Field: "status", Old: "doing", New: "done"},
+		{Time: day(4), TaskID: 2, Action: "move", Field: "status", Old: "done", New: "doing"},
+		{Time: day(5), TaskID: 2, Action: "move", Field: "status", Old: "doing", New: "done"},
+		{Time: day(1), TaskID: 3, Action: "add", Field: "title"},
+		{Time: day(2), TaskID: 3, Action: "move", Field: "status", Old: "doing", New: "done"},
+		{Time: day(3), TaskID: 3, Action: "move", Field: "status", Old: "done", New: "doing"},
+		{Time: day(1), TaskID: 4, Action: "add", Field: "title"},
+		{Time: day(2), TaskID: 4, Action: "move", Field: "status", Old: "doing", New: "done"},
+	}
+
+	if got := backfillTimestamps(tasks, events); got != 3 {
+		t.Errorf("補完した件数 = %d, want 3", got)
+	}
+
+	tests := []struct {
+		id                         int
+		created, started, complete *time.Time
+	}{
+		{1, timePtr(day(1)), timePtr(day(2)), timePtr(day(3))},
+		{2, timePtr(day(1)), timePtr(day(2)), timePtr(day(5))}, // 最後の完了を使う
+		{3, timePtr(day(1)), timePtr(day(3)), nil},             // 完了していないので完了日時は補完しない
+		{4, &recorded, &recorded, &recorded},                   // 記録済みの日時は変更しない
+		{5, nil, nil, nil},                                     // 履歴がなければそのまま
+	}
+	for i, tt := range tests {
+		task := tasks[i]
+		for _, f := range []struct {
+			name      string
+			got, want *time.Time
+		}{
+			{"created_at", task.CreatedAt, tt.created},
+			{"started_at", task.StartedAt, tt.started},
+			{"completed_at", task.CompletedAt, tt.complete},
+		} {
+			if (f.got == nil) != (f.want == nil) || f.got != nil && !f.got.Equal(*f.want) {
+				t.Errorf("#%d の %s = %v, want %v", tt.id, f.name, f.got, f.want)
+			}
+		}
+	}
+}
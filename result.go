@@ -0,0 +1,149 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/rivo/tview"
+)
+
+// コマンドの出力は画面に直接書かず output（Result）に追加します。
+// CLI・スプリントコンソール・TUI は execute が返す Result を表示するだけなので、
+// どの画面から実行しても同じ内容になります。
+
+// EntryKind は出力の種類です。
+type EntryKind int
+
+const (
+	EntryText    EntryKind = iota // 通常のメッセージ
+	EntryWarning                  // 警告（キャパシティ超過、未チェックの受け入れ条件など）
+	EntryTable                    // 表
+)
+
+// Entry は出力の1項目です。
+type Entry struct {
+	Kind  EntryKind
+	Text  string
+	Table *Table
+}
+
+// Table は表形式の出力です。Title があれば表の前に見出しとして表示します。
+type Table struct {
+	Title  string
+	Header []string
+	Rows   [][]string
+}
+
+func (t *Table) Append(row []string) {
+	t.Rows = append(t.Rows, row)
+}
+
+// Result はコマンドの実行結果です。
+type Result struct {
+	Entries []Entry
+	Err     error
+	live    func(Entry) // 追加されたそばから表示する場合（対話的なコマンドで入力と出力の順序を保つため）
+}
+
+func (r *Result) add(e Entry) {
+	r.Entries = append(r.Entries, e)
+	if r.live != nil {
+		r.live(e)
+	}
+}
+
+func (r *Result) Printf(format string, a ...interface{}) {
+	r.add(Entry{Kind: EntryText, Text: fmt.Sprintf(format, a...)})
+}
+
+func (r *Result) Println(a ...interface{}) {
+	r.add(Entry{Kind: EntryText, Text: strings.TrimSuffix(fmt.Sprintln(a...), "\n")})
+}
+
+func (r *Result) Warnf(format string, a ...interface{}) {
+	r.add(Entry{Kind: EntryWarning, Text: fmt.Sprintf(format, a...)})
+}
+
+func (r *Result) AddTable(t *Table) {
+	r.add(Entry{Kind: EntryTable, Table: t})
+}
+
+// output は実行中のコマンドの出力先です。コマンドの外（タイマーの表示など）では標準出力に表示します。
+var output = &Result{live: printEntry}
+
+var outputMu sync.Mutex
+
+// collect は fn の出力を新しい Result に集めて返します。live を指定すると出力を逐次受け取れます。
+// 同時に実行されるコマンド（タイマーの表示とコンソールの入力など）の出力が混ざらないよう排他します。
+func collect(live func(Entry), fn func() error) *Result {
+	outputMu.Lock()
+	defer outputMu.Unlock()
+	return capture(live, fn)
+}
+
+// capture は排他せずに fn の出力を集めます。
+func capture(live func(Entry), fn func() error) *Result {
+	saved := output
+	defer func() { output = saved }()
+
+	res := &Result{live: live}
+	output = res
+	res.Err = fn()
+	return res
+}
+
+// printEntry は出力の1項目を端末向けに標準出力へ表示します。
+func printEntry(e Entry) {
+	writeEntry(os.Stdout, e)
+}
+
+// writeEntry は出力の1項目を端末向けのテキストにして w に書き出します。
+func writeEntry(w io.Writer, e Entry) {
+	switch e.Kind {
+	case EntryWarning:
+		fmt.Fprintln(w, "[警告]", e.Text)
+	case EntryTable:
+		if e.Table.Title != "" {
+			fmt.Fprintf(w, "\n=== %s ===\n", e.Table.Title)
+		}
+		table := tablewriter.NewWriter(w)
+		table.SetHeader(e.Table.Header)
+		table.AppendBulk(e.Table.Rows)
+		table.Render()
+	default:
+		fmt.Fprintln(w, e.Text)
+	}
+}
+
+// String は結果を端末向けのテキストにします。
+func (r *Result) String() string {
+	var buf bytes.Buffer
+	for _, e := range r.Entries {
+		writeEntry(&buf, e)
+	}
+	return buf.String()
+}
+
+// writeResultTUI はコマンドの結果を TUI の色付きテキストにして書き出します。
+// 警告は黄色、エラーは赤で表示し、ラベルの色（ANSI）は tview の色に変換します。
+func writeResultTUI(out io.Writer, res *Result) {
+	for _, e := range res.Entries {
+		var buf bytes.Buffer
+		writeEntry(&buf, e)
+		text := tview.TranslateANSI(tview.Escape(buf.String()))
+		if e.Kind == EntryWarning {
+			text = "[yellow]" + text + "[-]"
+		}
+		fmt.Fprint(out, text)
+	}
+	if res.Err != nil {
+		var buf bytes.Buffer
+		printError(&buf, res.Err)
+		fmt.Fprint(out, "[red]"+tview.Escape(buf.String())+"[-]")
+	}
+}
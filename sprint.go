@@ -0,0 +1,522 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// スプリントの状態
+const (
+	SprintPlanned = "planned"
+	SprintActive  = "active"
+	SprintClosed  = "closed"
+)
+
+// Sprint はスプリントの情報です。タスクとは Task.SprintNumber で紐づきます。
+type Sprint struct {
+	Number    int            `json:"number"`
+	Name      string         `json:"name,omitempty"`
+	Goal      string         `json:"goal,omitempty"`
+	StartDate string         `json:"start_date,omitempty"` // YYYY-MM-DD
+	EndDate   string         `json:"end_date,omitempty"`   // YYYY-MM-DD
+	Capacity  map[string]int `json:"capacity,omitempty"`   // 担当者 → 対応できるタスクウェイト
+	State     string         `json:"state"`
+	ClosedAt  *time.Time     `json:"closed_at,omitempty"`
+	CarryOver CarryOverStats `json:"carry_over"` // 終了時の未完了タスクの扱い
+}
+
+const dateLayout = "2006-01-02"
+
+// checkSprintOpen はタスクを追加できるスプリントかどうかを確認します。
+func checkSprintOpen(number int) error {
+	sp, err := store.GetSprint(number)
+	if err == ErrSprintNotFound {
+		return notFound(err, "スプリント %d は存在しません（sprint create %d で作成してください）", number, number)
+	}
+	if err != nil {
+		return err
+	}
+	if sp.State == SprintClosed {
+		return fmt.Errorf("スプリント %d は終了しています", number)
+	}
+	return nil
+}
+
+// currentSprint は進行中（active）のスプリントの番号を返します。進行中のスプリントがなければ 0 です。
+// スプリントの記録がまだない以前のデータでは、タイマー設定のスプリント番号を使います。
+func currentSprint() (int, error) {
+	sprints, err := store.ListSprints()
+	if err != nil {
+		return 0, err
+	}
+	for _, sp := range sprints {
+		if sp.State == SprintActive {
+			return sp.Number, nil
+		}
+	}
+	if len(sprints) > 0 {
+		return 0, nil
+	}
+	settings, err := loadTimerSettings()
+	if err != nil || settings == nil {
+		return 0, err
+	}
+	return settings.SprintNumber, nil
+}
+
+// capacityFlag は sprint create の --capacity <名前>=<ウェイト>（複数指定可）です。
+type capacityFlag map[string]int
+
+func (c capacityFlag) String() string {
+	parts := make([]string, 0, len(c))
+	for name, n := range c {
+		parts = append(parts, fmt.Sprintf("%s=%d", name, n))
+	}
+	sort.Strings(parts)
+	return strings.Join(parts, ",")
+}
+
+func (c capacityFlag) Set(value string) error {
+	name, points, ok := strings.Cut(value, "=")
+	n, err := strconv.Atoi(points)
+	if !ok || name == "" || err != nil || n < 0 {
+		return fmt.Errorf("<名前>=<ウェイト> で指定してください")
+	}
+	c[name] = n
+	return nil
+}
+
+// dateFlag は YYYY-MM-DD 形式の日付のオプションです。
+type dateFlag string
+
+func (d *dateFlag) String() string { return string(*d) }
+
+func (d *dateFlag) Set(value string) error {
+	if _, err := time.Parse(dateLayout, value); err != nil {
+		return fmt.Errorf("YYYY-MM-DD で指定してください")
+	}
+	*d = dateFlag(value)
+	return nil
+}
+
+// CreateSprint はスプリントを作成します。sp.State は planned になります。
+func CreateSprint(sp Sprint) error {
+	unlock, err := store.Lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	if _, err := store.GetSprint(sp.Number); err == nil {
+		return fmt.Errorf("スプリント %d は既に存在します", sp.Number)
+	} else if err != ErrSprintNotFound {
+		return err
+	}
+	if sp.StartDate != "" && sp.EndDate != "" && sp.EndDate < sp.StartDate {
+		return fmt.Errorf("終了日が開始日より前です")
+	}
+
+	sp.State = SprintPlanned
+	if len(sp.Capacity) == 0 {
+		sp.Capacity = nil
+	}
+	if err := store.SaveSprint(sp); err != nil {
+		return err
+	}
+	output.Printf("スプリント %d を作成しました", sp.Number)
+	return nil
+}
+
+// StartSprint はスプリントを開始します。以降、タイマーやバーンダウンはこのスプリントを対象にします。
+func StartSprint(number int) error {
+	unlock, err := store.Lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	sprints, err := store.ListSprints()
+	if err != nil {
+		return err
+	}
+	var target *Sprint
+	for i, sp := range sprints {
+		if sp.State == SprintActive && sp.Number != number {
+			return fmt.Errorf("スプリント %d が進行中です。先に終了してください", sp.Number)
+		}
+		if sp.Number == number {
+			target = &sprints[i]
+		}
+	}
+	if target == nil {
+		return notFound(ErrSprintNotFound, "スプリント %d は存在しません", number)
+	}
+	if target.State != SprintPlanned {
+		return fmt.Errorf("スプリント %d は %s のため開始できません", number, target.State)
+	}
+
+	target.State = SprintActive
+	if target.StartDate == "" {
+		target.StartDate = time.Now().Format(dateLayout)
+	}
+	if err := store.SaveSprint(*target); err != nil {
+		return err
+	}
+	output.Printf("スプリント %d を開始しました", number)
+	return nil
+}
+
+// 未完了タスクの扱い
+const (
+	carryNext    = "carry"   // 次のスプリントへ持ち越す
+	carryBacklog = "backlog" // バックログへ戻す
+	carryDrop    = "drop"    // 削除する
+)
+
+// CarryOverStats はスプリント終了時の未完了タスクの扱いの集計です。
+type CarryOverStats struct {
+	Carried          int                `json:"carried"`
+	CarriedWeight    int                `json:"carried_weight"`
+	CarriedLoad      map[string]float64 `json:"carried_load,omitempty"` // 担当者 → 持ち越したウェイト（配分比率で按分）
+	Backlogged       int                `json:"backlogged"`
+	BackloggedWeight int                `json:"backlogged_weight"`
+	Dropped          int                `json:"dropped"`
+	DroppedWeight    int                `json:"dropped_weight"`
+}
+
+// removedWeight は終了時にスプリントから外れた未完了タスク（持ち越し・バックログ・削除）のウェイトの合計です。
+func (s CarryOverStats) removedWeight() int {
+	return s.CarriedWeight + s.BackloggedWeight + s.DroppedWeight
+}
+
+// CloseSprint はスプリントを終了します。終了したスプリントにはタスクを追加できません。
+// 未完了のタスクは、mode が空なら1件ずつ尋ね、それ以外は mode（carry / backlog / drop）で一括処理します。
+func CloseSprint(number int, mode string) error {
+	if mode != "" && mode != carryNext && mode != carryBacklog && mode != carryDrop {
+		return fmt.Errorf("未完了タスクの扱いは %s / %s / %s のいずれかで指定してください", carryNext, carryBacklog, carryDrop)
+	}
+	unlock, err := store.Lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	sp, err := store.GetSprint(number)
+	if err == ErrSprintNotFound {
+		return notFound(err, "スプリント %d は存在しません", number)
+	}
+	if err != nil {
+		return err
+	}
+	if sp.State == SprintClosed {
+		return fmt.Errorf("スプリント %d は既に終了しています", number)
+	}
+
+	tasks, err := store.List()
+	if err != nil {
+		return err
+	}
+	if ids := duplicateIDs(tasks); len(ids) > 0 {
+		return fmt.Errorf("重複したタスクIDがあります %v。先に doctor --fix で修復してください", ids)
+	}
+	unfinished := []Task{}
+	for _, t := range tasks {
+		if t.SprintNumber == number && !t.IsDone() {
+			unfinished = append(unfinished, t)
+		}
+	}
+
+	backlogRank := nextBacklogRank(tasks)
+	stats := CarryOverStats{}
+	if len(unfinished) > 0 {
+		output.Printf("スプリント %d の未完了タスク: %d 件", number, len(unfinished))
+		table := &Table{Header: []string{"ID", "Title", "Weight", "Assignees", "Status"}}
+		for _, t := range unfinished {
+			table.Append([]string{strconv.Itoa(t.ID), t.Title, strconv.Itoa(t.TaskWeight), t.Assignees.String(), t.Status})
+		}
+		output.AddTable(table)
+
+		// 持ち越し先のスプリント（なければ作成）
+		next, err := store.GetSprint(number + 1)
+		if err == ErrSprintNotFound {
+			next = Sprint{Number: number + 1, State: SprintPlanned}
+		} else if err != nil {
+			return err
+		}
+
+		// 途中で入力が終わった場合に一部だけ処理されないよう、先にすべての扱いを決める
+		actions := make([]string, len(unfinished))
+		for i, t := range unfinished {
+			actions[i] = mode
+			if mode == "" {
+				action, ok := askCarryAction(stdin, t, next.Number)
+				if !ok {
+					return usageErrorf("未完了タスクの扱いを入力できませんでした（対話できない場合は --unfinished %s / %s / %s で指定してください）", carryNext, carryBacklog, carryDrop)
+				}
+				actions[i] = action
+			}
+		}
+
+		// 子タスクのある親タスクは削除できないため、子タスクを先に処理する
+		children := childrenOf(tasks)
+		order := make([]int, 0, len(unfinished))
+		for i, t := range unfinished {
+			if len(children[t.ID]) == 0 {
+				order = append(order, i)
+			}
+		}
+		for i, t := range unfinished {
+			if len(children[t.ID]) > 0 {
+				order = append(order, i)
+			}
+		}
+		dropped := make(map[int]bool)
+		for _, i := range order {
+			t, action := unfinished[i], actions[i]
+			if action == carryNext && next.State == SprintClosed {
+				output.Printf("スプリント %d は終了しているため、#%d はバックログへ戻します", next.Number, t.ID)
+				action = carryBacklog
+			}
+
+			before := t
+			switch action {
+			case carryNext:
+				t.SprintNumber = next.Number
+				t.CarryOver++
+				stats.Carried++
+				stats.CarriedWeight += t.TaskWeight
+				for name, w := range t.Assignees.WeightShares(t.TaskWeight) {
+					if stats.CarriedLoad == nil {
+						stats.CarriedLoad = make(map[string]float64)
+					}
+					stats.CarriedLoad[name] += w
+				}
+			case carryBacklog:
+				t.SprintNumber = 0
+				t.Rank = backlogRank
+				backlogRank++
+				stats.Backlogged++
+				stats.BackloggedWeight += t.TaskWeight
+			case carryDrop:
+				if n := remainingChildren(children[t.ID], dropped); n > 0 {
+					output.Warnf("タスク #%d には子タスクが %d 件あるため削除せず、バックログへ戻します", t.ID, n)
+					t.SprintNumber = 0
+					t.Rank = backlogRank
+					backlogRank++
+					stats.Backlogged++
+					stats.BackloggedWeight += t.TaskWeight
+					action = carryBacklog
+					break
+				}
+				if err := store.Delete(t.ID); err != nil {
+					return err
+				}
+				recordEvent(t, "close", "task", taskSnapshot(t), "")
+				recordTaskOp(fmt.Sprintf("drop #%d", t.ID), t.ID, &before, nil)
+				dropped[t.ID] = true
+				stats.Dropped++
+				stats.DroppedWeight += t.TaskWeight
+				continue
+			default:
+				continue // そのまま残す
+			}
+			if err := store.Update(t); err != nil {
+				return err
+			}
+			recordEvent(t, "close", "sprint_number", strconv.Itoa(before.SprintNumber), strconv.Itoa(t.SprintNumber))
+			recordTaskOp(fmt.Sprintf("%s #%d", action, t.ID), t.ID, &before, &t)
+		}
+
+		if stats.Carried > 0 {
+			if _, err := store.GetSprint(next.Number); err == ErrSprintNotFound {
+				if err := store.SaveSprint(next); err != nil {
+					return err
+				}
+				output.Printf("スプリント %d を作成しました", next.Number)
+			}
+		}
+	}
+
+	sp.State = SprintClosed
+	sp.ClosedAt = timePtr(time.Now())
+	sp.CarryOver = stats
+	if err := store.SaveSprint(sp); err != nil {
+		return err
+	}
+	output.Printf("スプリント %d を終了しました（持ち越し: %d 件 / バックログ: %d 件 / 削除: %d 件）",
+		number, stats.Carried, stats.Backlogged, stats.Dropped)
+	return nil
+}
+
+// duplicateIDs は複数のタスクで使われている ID を返します。
+func duplicateIDs(tasks []Task) []int {
+	count := make(map[int]int)
+	ids := []int{}
+	for _, t := range tasks {
+		count[t.ID]++
+		if count[t.ID] == 2 {
+			ids = append(ids, t.ID)
+		}
+	}
+	return ids
+}
+
+// remainingChildren は子タスクのうち、削除されていないものの件数です。
+func remainingChildren(children []Task, dropped map[int]bool) int {
+	n := 0
+	for _, c := range children {
+		if !dropped[c.ID] {
+			n++
+		}
+	}
+	return n
+}
+
+// askCarryAction は未完了タスク1件の扱いを尋ねます。入力が終わった場合は ok が false になります。
+func askCarryAction(sc *bufio.Scanner, t Task, next int) (action string, ok bool) {
+	for {
+		fmt.Printf("#%d %s (%dpt)\n  [Enter]/c=スプリント %d へ持ち越し, b=バックログへ戻す, d=削除 > ", t.ID, t.Title, t.TaskWeight, next)
+		if !sc.Scan() {
+			fmt.Println()
+			return "", false
+		}
+		switch strings.TrimSpace(sc.Text()) {
+		case "", "c":
+			return carryNext, true
+		case "b":
+			return carryBacklog, true
+		case "d":
+			return carryDrop, true
+		default:
+			output.Println("c / b / d のいずれかを入力してください")
+		}
+	}
+}
+
+// ShowSprint はスプリントの詳細と担当者ごとのキャパシティを表示します。
+func ShowSprint(number int) error {
+	sp, err := store.GetSprint(number)
+	if err == ErrSprintNotFound {
+		return notFound(err, "スプリント %d は存在しません", number)
+	}
+	if err != nil {
+		return err
+	}
+	tasks, err := store.List()
+	if err != nil {
+		return err
+	}
+
+	heading := fmt.Sprintf("スプリント %d", sp.Number)
+	if sp.Name != "" {
+		heading += fmt.Sprintf(" 「%s」", sp.Name)
+	}
+	output.Printf("%s [%s]", heading, sp.State)
+	if sp.Goal != "" {
+		output.Println("ゴール:", sp.Goal)
+	}
+	output.Printf("期間: %s 〜 %s", orDash(sp.StartDate), orDash(sp.EndDate))
+
+	committed, done, count := 0, 0, 0
+	load := make(map[string]float64)
+	for _, t := range tasks {
+		if t.SprintNumber != number {
+			continue
+		}
+		count++
+		committed += t.TaskWeight
+		if t.IsDone() {
+			done += t.TaskWeight
+		}
+		for name, w := range t.Assignees.WeightShares(t.TaskWeight) {
+			load[name] += w
+		}
+	}
+	// 持ち越し・バックログへ戻したタスクは別のスプリントに移り、削除したタスクは残っていないため、
+	// 終了時に記録したウェイトを計画に含める
+	committed += sp.CarryOver.removedWeight()
+	for name, w := range sp.CarryOver.CarriedLoad {
+		load[name] += w
+	}
+	output.Printf("タスク: %d 件  完了ウェイト/計画ウェイト: %d/%d", count, done, committed)
+	if sp.State == SprintClosed {
+		output.Printf("終了時の未完了タスク: 持ち越し %d 件（%dpt） / バックログ %d 件（%dpt） / 削除 %d 件（%dpt）",
+			sp.CarryOver.Carried, sp.CarryOver.CarriedWeight, sp.CarryOver.Backlogged, sp.CarryOver.BackloggedWeight,
+			sp.CarryOver.Dropped, sp.CarryOver.DroppedWeight)
+	}
+
+	names := make([]string, 0, len(sp.Capacity)+len(load))
+	for name := range sp.Capacity {
+		names = append(names, name)
+	}
+	for name := range load {
+		if _, ok := sp.Capacity[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 {
+		return nil
+	}
+	sort.Strings(names)
+
+	table := &Table{Header: []string{"Assignee", "Capacity", "Assigned"}}
+	for _, name := range names {
+		capacity := "-"
+		if c, ok := sp.Capacity[name]; ok {
+			capacity = strconv.Itoa(c)
+		}
+		table.Append([]string{name, capacity, fmt.Sprintf("%.1f", load[name])})
+	}
+	output.AddTable(table)
+	return nil
+}
+
+// ListSprints はスプリントの一覧を表示します。
+func ListSprints() error {
+	sprints, err := store.ListSprints()
+	if err != nil {
+		return err
+	}
+	tasks, err := store.List()
+	if err != nil {
+		return err
+	}
+	weight := make(map[int]int)
+	count := make(map[int]int)
+	for _, t := range tasks {
+		weight[t.SprintNumber] += t.TaskWeight
+		count[t.SprintNumber]++
+	}
+
+	table := &Table{Header: []string{"Sprint", "Name", "State", "Start", "End", "Goal", "Tasks", "Weight", "Carried Out"}}
+	for _, sp := range sprints {
+		carried := "-"
+		if sp.State == SprintClosed {
+			carried = strconv.Itoa(sp.CarryOver.Carried)
+		}
+		table.Append([]string{
+			strconv.Itoa(sp.Number),
+			sp.Name,
+			sp.State,
+			orDash(sp.StartDate),
+			orDash(sp.EndDate),
+			sp.Goal,
+			strconv.Itoa(count[sp.Number]),
+			strconv.Itoa(weight[sp.Number]),
+			carried,
+		})
+	}
+	output.AddTable(table)
+	return nil
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
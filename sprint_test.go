@@ -0,0 +1,206 @@
+package main
+
+import (
+	"errors"
+	"testing"
+)
+
+// setupSprint はスプリント 1 と、その中のタスクを用意します。
+func setupSprint(t *testing.T, tasks ...Task) {
+	t.Helper()
+	setupStore(t, tasks...)
+	if err := store.SaveSprint(Sprint{Number: 1, State: SprintActive}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func getSprint(t *testing.T, number int) Sprint {
+	t.Helper()
+	sp, err := store.GetSprint(number)
+	if err != nil {
+		t.Fatalf("スプリント %d: %v", number, err)
+	}
+	return sp
+}
+
+func TestCloseSprintCarry(t *testing.T) {
+	setupSprint(t,
+		Task{ID: 1, Title: "done", Status: "done", SprintNumber: 1, TaskWeight: 3},
+		Task{ID: 2, Title: "open", Status: "doing", SprintNumber: 1, TaskWeight: 5, Assignees: AssigneeList{{Name: "taro"}}},
+	)
+	mustRun(t, "sprint", "close", "1", "--unfinished", "carry")
+
+	task := getTask(t, 2)
+	if task.SprintNumber != 2 || task.CarryOver != 1 {
+		t.Errorf("#2 = sprint %d, carry_over %d, want sprint 2, carry_over 1", task.SprintNumber, task.CarryOver)
+	}
+	sp := getSprint(t, 1)
+	if sp.State != SprintClosed || sp.CarryOver.Carried != 1 || sp.CarryOver.CarriedWeight != 5 {
+		t.Errorf("スプリント 1 = %s %+v", sp.State, sp.CarryOver)
+	}
+	if next := getSprint(t, 2); next.State != SprintPlanned {
+		t.Errorf("持ち越し先のスプリント 2 = %s, want planned", next.State)
+	}
+
+	// 持ち越したウェイトは終了したスプリントの計画ウェイトに含める
+	sprints, err := store.ListSprints()
+	if err != nil {
+		t.Fatal(err)
+	}
+	velocities := collectVelocity(loadTasks(t), sprints)
+	if v := velocities[0]; v.Sprint != 1 || v.Committed != 8 || v.Completed != 3 {
+		t.Errorf("スプリント 1 のベロシティ = %+v, want committed 8, completed 3", v)
+	}
+	_, team, err := sprintCapacity(loadTasks(t), 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if team.Load != 8 {
+		t.Errorf("スプリント 1 の負荷 = %.1f, want 8", team.Load)
+	}
+}
+
+func TestCloseSprintBacklog(t *testing.T) {
+	setupSprint(t,
+		Task{ID: 1, Title: "backlog", Status: "todo", TaskWeight: 1, Rank: 1},
+		Task{ID: 2, Title: "open", Status: "todo", SprintNumber: 1, TaskWeight: 2},
+	)
+	mustRun(t, "sprint", "close", "1", "--unfinished", "backlog")
+
+	task := getTask(t, 2)
+	if task.SprintNumber != 0 || task.Rank != 2 {
+		t.Errorf("#2 = sprint %d, rank %d, want バックログの末尾（rank 2）", task.SprintNumber, task.Rank)
+	}
+	if got := getSprint(t, 1).CarryOver.Backlogged; got != 1 {
+		t.Errorf("backlogged = %d, want 1", got)
+	}
+}
+
+func TestCloseSprintDropKeepsParents(t *testing.T) {
+	setupSprint(t,
+		Task{ID: 1, Title: "parent", Status: "todo", SprintNumber: 1, TaskWeight: 5},
+		Task{ID: 2, Title: "child", Status: "todo", SprintNumber: 1, TaskWeight: 2, ParentID: 1},
+		Task{ID: 3, Title: "parent with done child", Status: "todo", SprintNumber: 1, TaskWeight: 3},
+		Task{ID: 4, Title: "done child", Status: "done", SprintNumber: 1, TaskWeight: 1, ParentID: 3},
+	)
+	mustRun(t, "sprint", "close", "1", "--unfinished", "drop")
+
+	// 子タスクも削除する親は削除できるが、完了した子タスクが残る親はバックログへ戻す
+	tasks := loadTasks(t)
+	if len(tasks) != 2 {
+		t.Fatalf("タスク数 = %d, want 2: %+v", len(tasks), tasks)
+	}
+	if task := getTask(t, 3); task.SprintNumber != 0 {
+		t.Errorf("#3 のスプリント = %d, want 0（バックログ）", task.SprintNumber)
+	}
+	if task := getTask(t, 4); task.ParentID != 3 {
+		t.Errorf("#4 の親 = %d, want 3", task.ParentID)
+	}
+	stats := getSprint(t, 1).CarryOver
+	if stats.Dropped != 2 || stats.Backlogged != 1 {
+		t.Errorf("集計 = %+v, want dropped 2, backlogged 1", stats)
+	}
+}
+
+func TestCloseSprintInteractive(t *testing.T) {
+	setupSprint(t,
+		Task{ID: 1, Title: "a", Status: "todo", SprintNumber: 1, TaskWeight: 1},
+		Task{ID: 2, Title: "b", Status: "todo", SprintNumber: 1, TaskWeight: 2},
+	)
+	setInput(t, "b\n\n")
+	mustRun(t, "sprint", "close", "1")
+
+	if got := getTask(t, 1).SprintNumber; got != 0 {
+		t.Errorf("#1 のスプリント = %d, want 0", got)
+	}
+	if got := getTask(t, 2).SprintNumber; got != 2 {
+		t.Errorf("#2 のスプリント = %d, want 2", got)
+	}
+}
+
+func TestCloseSprintEOFAborts(t *testing.T) {
+	setupSprint(t,
+		Task{ID: 1, Title: "a", Status: "todo", SprintNumber: 1, TaskWeight: 1},
+		Task{ID: 2, Title: "b", Status: "todo", SprintNumber: 1, TaskWeight: 2},
+	)
+	// 1件目だけ答えて入力が終わった場合も、何も変更しない
+	setInput(t, "d\n")
+
+	res := run(t, "sprint", "close", "1")
+	if got := exitCode(res.Err); got != exitUsage {
+		t.Fatalf("exit code = %d, want %d (%v)", got, exitUsage, res.Err)
+	}
+	if got := len(loadTasks(t)); got != 2 {
+		t.Errorf("タスク数 = %d, want 2", got)
+	}
+	if got := getSprint(t, 1).State; got != SprintActive {
+		t.Errorf("スプリント 1 = %s, want active", got)
+	}
+}
+
+func TestCloseSprintNextClosed(t *testing.T) {
+	setupSprint(t, Task{ID: 1, Title: "a", Status: "todo", SprintNumber: 1, TaskWeight: 1})
+	if err := store.SaveSprint(Sprint{Number: 2, State: SprintClosed}); err != nil {
+		t.Fatal(err)
+	}
+	mustRun(t, "sprint", "close", "1", "--unfinished", "carry")
+
+	if got := getTask(t, 1).SprintNumber; got != 0 {
+		t.Errorf("終了したスプリントへは持ち越さずバックログへ戻す: sprint = %d", got)
+	}
+}
+
+func TestCurrentSprint(t *testing.T) {
+	setupStore(t)
+	// スプリントの記録がない以前のデータでは、タイマー設定のスプリント番号を使う
+	if err := saveTimerSettings(&Timer{Plannning: 1, Development: 1, Review: 1, SprintNumber: 4}); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := currentSprint(); err != nil || got != 4 {
+		t.Errorf("スプリントの記録がないときの currentSprint() = %d, %v, want 4", got, err)
+	}
+
+	mustRun(t, "sprint", "create", "1")
+	mustRun(t, "sprint", "create", "2")
+	if got, err := currentSprint(); err != nil || got != 0 {
+		t.Errorf("進行中のスプリントがないときの currentSprint() = %d, %v, want 0", got, err)
+	}
+	mustRun(t, "sprint", "start", "2")
+	if got, err := currentSprint(); err != nil || got != 2 {
+		t.Errorf("currentSprint() = %d, %v, want 2", got, err)
+	}
+	// sprint start はタイマー設定を書き換えない
+	if settings, err := loadTimerSettings(); err != nil || settings.SprintNumber != 4 {
+		t.Errorf("タイマー設定のスプリント番号 = %+v, %v, want 4 のまま", settings, err)
+	}
+}
+
+func TestCloseSprintInTUI(t *testing.T) {
+	setupSprint(t, Task{ID: 1, Title: "a", Status: "todo", SprintNumber: 1, TaskWeight: 1})
+
+	// TUI では対話できないため、--unfinished がなければ何も変更しない
+	res := execute([]string{"sprint", "close", "1"}, frontendTUI, nil)
+	var usage *usageError
+	if !errors.As(res.Err, &usage) {
+		t.Fatalf("--unfinished なしの err = %v, want usageError", res.Err)
+	}
+	if sp := getSprint(t, 1); sp.State != SprintActive {
+		t.Errorf("スプリント 1 の状態 = %s, want active のまま", sp.State)
+	}
+
+	res = execute([]string{"sprint", "close", "1", "--unfinished", "backlog"}, frontendTUI, nil)
+	if res.Err != nil {
+		t.Fatalf("--unfinished ありの err = %v", res.Err)
+	}
+	if sp := getSprint(t, 1); sp.State != SprintClosed {
+		t.Errorf("スプリント 1 の状態 = %s, want closed", sp.State)
+	}
+	if got := getTask(t, 1).SprintNumber; got != 0 {
+		t.Errorf("#1 のスプリント = %d, want 0（バックログ）", got)
+	}
+
+	// 応答をオプションで指定できないコマンドは TUI では使えない
+	if res := execute([]string{"poker", "1", "taro"}, frontendTUI, nil); res.Err == nil {
+		t.Error("TUI で poker を実行できました")
+	}
+}
@@ -0,0 +1,325 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+)
+
+// TaskStore はタスク（およびスプリント）の保存先を抽象化するインターフェースです。
+// JSON ファイルと SQLite の2種類の実装があります。
+type TaskStore interface {
+	List() ([]Task, error)
+	Get(id int) (Task, error)
+	Create(task Task) (Task, error) // IDを採番して保存し、保存後のタスクを返す
+	Update(task Task) error
+	Delete(id int) error
+	// ReplaceAll は全タスクを置き換えます（doctor による修復などで使用）。
+	ReplaceAll(tasks []Task) error
+
+	ListSprints() ([]Sprint, error)
+	GetSprint(number int) (Sprint, error)
+	SaveSprint(sprint Sprint) error // 同じ番号があれば上書き
+
+	ListEpics() ([]Epic, error)
+	GetEpic(id int) (Epic, error)
+	SaveEpic(epic Epic) error // 同じ ID があれば上書き
+
+	// Lock は読み込み〜保存の間、他プロセスからの更新を防ぐ排他ロックを取得します。
+	Lock() (unlock func(), err error)
+
+	// Path はデータファイルのパスです。履歴や undo の記録はこの隣に置かれます。
+	Path() string
+}
+
+var ErrTaskNotFound = errors.New("task not found")
+var ErrSprintNotFound = errors.New("sprint not found")
+var ErrEpicNotFound = errors.New("epic not found")
+
+const configFile = "todo_config.json"
+
+type Config struct {
+	Store          string           `json:"store"`                     // "json" または "sqlite"
+	DataPath       string           `json:"data_path"`                 // 省略時は todo.json / todo.db
+	Workflow       *Workflow        `json:"workflow,omitempty"`        // 省略時は defaultWorkflow
+	CapacityPolicy string           `json:"capacity_policy,omitempty"` // "warn"（省略時）または "refuse"
+	Estimation     *EstimationScale `json:"estimation,omitempty"`      // 省略時は defaultEstimation（フィボナッチ）
+	ChartFont      string           `json:"chart_font,omitempty"`      // グラフ用の TTF/OTF（省略時は埋め込みの M+ 1p）
+}
+
+// store は各コマンドが使用するタスクの保存先です。main で初期化されます。
+var store TaskStore
+
+func loadConfig() (*Config, error) {
+	file, err := os.Open(configFile)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return &Config{}, nil // 設定ファイルがなければデフォルト
+		}
+		return nil, err
+	}
+	defer file.Close()
+
+	var cfg Config
+	if err := json.NewDecoder(file).Decode(&cfg); err != nil {
+		return nil, err
+	}
+	return &cfg, nil
+}
+
+// 付随ファイル（履歴・undo・隔離・スプリント・エピック）の名前です。データファイル名の後ろに付けます。
+const (
+	sidecarHistory    = ".history.jsonl"
+	sidecarJournal    = ".journal.json"
+	sidecarQuarantine = ".quarantine.json"
+	sidecarSprints    = ".sprints.json"
+	sidecarEpics      = ".epics.json"
+	sidecarLock       = ".lock"
+)
+
+// sidecarPath はデータファイルと同じ場所に置く付随ファイルのパスです（todo.json → todo.json.history.jsonl）。
+// 拡張子を含むデータファイル名全体をもとにするため、todo.json と todo.db の付随ファイルが混ざることはありません。
+func sidecarPath(dataPath, suffix string) string {
+	return dataPath + suffix
+}
+
+// openStore は設定に応じた TaskStore を生成します。
+func openStore(cfg *Config) (TaskStore, error) {
+	switch cfg.Store {
+	case "", "json":
+		path := cfg.DataPath
+		if path == "" {
+			path = dataFile
+		}
+		return &jsonTaskStore{path: path}, nil
+	case "sqlite":
+		path := cfg.DataPath
+		if path == "" {
+			path = sqliteDataFile
+		}
+		return newSQLiteTaskStore(path)
+	default:
+		return nil, fmt.Errorf("unknown store: %s", cfg.Store)
+	}
+}
+
+// ---- JSON ファイル実装 ---------------------------------------------
+
+type jsonTaskStore struct {
+	path string
+}
+
+func (s *jsonTaskStore) load() ([]Task, error) {
+	file, err := os.Open(s.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []Task{}, nil
+		}
+		return nil, err
+	}
+	defer file.Close()
+
+	var tasks []Task
+	if err := json.NewDecoder(file).Decode(&tasks); err != nil {
+		return nil, err
+	}
+	return tasks, nil
+}
+
+func (s *jsonTaskStore) save(tasks []Task) error {
+	return writeFileAtomic(s.path, func(w io.Writer) error {
+		return json.NewEncoder(w).Encode(tasks)
+	})
+}
+
+func (s *jsonTaskStore) Lock() (func(), error) {
+	return acquireLock(sidecarPath(s.path, sidecarLock))
+}
+
+func (s *jsonTaskStore) Path() string {
+	return s.path
+}
+
+func (s *jsonTaskStore) List() ([]Task, error) {
+	return s.load()
+}
+
+func (s *jsonTaskStore) Get(id int) (Task, error) {
+	tasks, err := s.load()
+	if err != nil {
+		return Task{}, err
+	}
+	for _, t := range tasks {
+		if t.ID == id {
+			return t, nil
+		}
+	}
+	return Task{}, ErrTaskNotFound
+}
+
+func (s *jsonTaskStore) Create(task Task) (Task, error) {
+	tasks, err := s.load()
+	if err != nil {
+		return Task{}, err
+	}
+	task.ID = nextID(tasks)
+	tasks = append(tasks, task)
+	if err := s.save(tasks); err != nil {
+		return Task{}, err
+	}
+	return task, nil
+}
+
+func (s *jsonTaskStore) Update(task Task) error {
+	tasks, err := s.load()
+	if err != nil {
+		return err
+	}
+	for i, t := range tasks {
+		if t.ID == task.ID {
+			tasks[i] = task
+			return s.save(tasks)
+		}
+	}
+	return ErrTaskNotFound
+}
+
+func (s *jsonTaskStore) Delete(id int) error {
+	tasks, err := s.load()
+	if err != nil {
+		return err
+	}
+
+	newTasks := make([]Task, 0, len(tasks))
+	isExist := false
+	for _, t := range tasks {
+		if t.ID == id {
+			isExist = true
+			continue // このタスクを削除（スキップ）
+		}
+		newTasks = append(newTasks, t)
+	}
+	if !isExist {
+		return ErrTaskNotFound
+	}
+	return s.save(newTasks)
+}
+
+func (s *jsonTaskStore) ReplaceAll(tasks []Task) error {
+	return s.save(tasks)
+}
+
+// sprintsPath はタスクファイルと同じ場所にあるスプリントファイルのパスです（todo.json → todo.json.sprints.json）。
+func (s *jsonTaskStore) sprintsPath() string {
+	return sidecarPath(s.path, sidecarSprints)
+}
+
+func (s *jsonTaskStore) ListSprints() ([]Sprint, error) {
+	file, err := os.Open(s.sprintsPath())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []Sprint{}, nil
+		}
+		return nil, err
+	}
+	defer file.Close()
+
+	var sprints []Sprint
+	if err := json.NewDecoder(file).Decode(&sprints); err != nil {
+		return nil, err
+	}
+	return sprints, nil
+}
+
+func (s *jsonTaskStore) GetSprint(number int) (Sprint, error) {
+	sprints, err := s.ListSprints()
+	if err != nil {
+		return Sprint{}, err
+	}
+	for _, sp := range sprints {
+		if sp.Number == number {
+			return sp, nil
+		}
+	}
+	return Sprint{}, ErrSprintNotFound
+}
+
+func (s *jsonTaskStore) SaveSprint(sprint Sprint) error {
+	sprints, err := s.ListSprints()
+	if err != nil {
+		return err
+	}
+	replaced := false
+	for i, sp := range sprints {
+		if sp.Number == sprint.Number {
+			sprints[i] = sprint
+			replaced = true
+		}
+	}
+	if !replaced {
+		sprints = append(sprints, sprint)
+		sort.Slice(sprints, func(i, j int) bool { return sprints[i].Number < sprints[j].Number })
+	}
+	return writeFileAtomic(s.sprintsPath(), func(w io.Writer) error {
+		return json.NewEncoder(w).Encode(sprints)
+	})
+}
+
+// epicsPath はタスクファイルと同じ場所にあるエピックファイルのパスです（todo.json → todo.json.epics.json）。
+func (s *jsonTaskStore) epicsPath() string {
+	return sidecarPath(s.path, sidecarEpics)
+}
+
+func (s *jsonTaskStore) ListEpics() ([]Epic, error) {
+	file, err := os.Open(s.epicsPath())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []Epic{}, nil
+		}
+		return nil, err
+	}
+	defer file.Close()
+
+	var epics []Epic
+	if err := json.NewDecoder(file).Decode(&epics); err != nil {
+		return nil, err
+	}
+	return epics, nil
+}
+
+func (s *jsonTaskStore) GetEpic(id int) (Epic, error) {
+	epics, err := s.ListEpics()
+	if err != nil {
+		return Epic{}, err
+	}
+	for _, e := range epics {
+		if e.ID == id {
+			return e, nil
+		}
+	}
+	return Epic{}, ErrEpicNotFound
+}
+
+func (s *jsonTaskStore) SaveEpic(epic Epic) error {
+	epics, err := s.ListEpics()
+	if err != nil {
+		return err
+	}
+	replaced := false
+	for i, e := range epics {
+		if e.ID == epic.ID {
+			epics[i] = epic
+			replaced = true
+		}
+	}
+	if !replaced {
+		epics = append(epics, epic)
+		sort.Slice(epics, func(i, j int) bool { return epics[i].ID < epics[j].ID })
+	}
+	return writeFileAtomic(s.epicsPath(), func(w io.Writer) error {
+		return json.NewEncoder(w).Encode(epics)
+	})
+}
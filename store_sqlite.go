@@ -0,0 +1,260 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+
+	_ "modernc.org/sqlite" // pure-Go の SQLite ドライバ
+)
+
+const sqliteDataFile = "todo.db"
+
+// sqliteTaskStore はタスクを SQLite に保存する TaskStore 実装です。
+// Task の項目追加に追従しやすいよう、本体は JSON として data 列に格納します。
+type sqliteTaskStore struct {
+	db   *sql.DB
+	path string
+}
+
+func newSQLiteTaskStore(path string) (*sqliteTaskStore, error) {
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := db.Exec(`CREATE TABLE IF NOT EXISTS tasks (
+		id   INTEGER PRIMARY KEY,
+		data TEXT NOT NULL
+	)`); err != nil {
+		db.Close()
+		return nil, err
+	}
+	if _, err := db.Exec(`CREATE TABLE IF NOT EXISTS sprints (
+		number INTEGER PRIMARY KEY,
+		data   TEXT NOT NULL
+	)`); err != nil {
+		db.Close()
+		return nil, err
+	}
+	if _, err := db.Exec(`CREATE TABLE IF NOT EXISTS epics (
+		id   INTEGER PRIMARY KEY,
+		data TEXT NOT NULL
+	)`); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &sqliteTaskStore{db: db, path: path}, nil
+}
+
+func (s *sqliteTaskStore) List() ([]Task, error) {
+	rows, err := s.db.Query(`SELECT data FROM tasks ORDER BY id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tasks := []Task{}
+	for rows.Next() {
+		var data string
+		if err := rows.Scan(&data); err != nil {
+			return nil, err
+		}
+		var t Task
+		if err := json.Unmarshal([]byte(data), &t); err != nil {
+			return nil, err
+		}
+		tasks = append(tasks, t)
+	}
+	return tasks, rows.Err()
+}
+
+func (s *sqliteTaskStore) Get(id int) (Task, error) {
+	var data string
+	err := s.db.QueryRow(`SELECT data FROM tasks WHERE id = ?`, id).Scan(&data)
+	if err == sql.ErrNoRows {
+		return Task{}, ErrTaskNotFound
+	}
+	if err != nil {
+		return Task{}, err
+	}
+	var t Task
+	if err := json.Unmarshal([]byte(data), &t); err != nil {
+		return Task{}, err
+	}
+	return t, nil
+}
+
+func (s *sqliteTaskStore) Create(task Task) (Task, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return Task{}, err
+	}
+	defer tx.Rollback()
+
+	var maxID int
+	if err := tx.QueryRow(`SELECT COALESCE(MAX(id), 0) FROM tasks`).Scan(&maxID); err != nil {
+		return Task{}, err
+	}
+	task.ID = maxID + 1
+	data, err := json.Marshal(task)
+	if err != nil {
+		return Task{}, err
+	}
+	if _, err := tx.Exec(`INSERT INTO tasks (id, data) VALUES (?, ?)`, task.ID, string(data)); err != nil {
+		return Task{}, err
+	}
+	return task, tx.Commit()
+}
+
+func (s *sqliteTaskStore) Update(task Task) error {
+	data, err := json.Marshal(task)
+	if err != nil {
+		return err
+	}
+	res, err := s.db.Exec(`UPDATE tasks SET data = ? WHERE id = ?`, string(data), task.ID)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrTaskNotFound
+	}
+	return nil
+}
+
+func (s *sqliteTaskStore) Delete(id int) error {
+	res, err := s.db.Exec(`DELETE FROM tasks WHERE id = ?`, id)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrTaskNotFound
+	}
+	return nil
+}
+
+// Lock は Get → Update の一連の操作を他プロセスと直列化するためのロックです。
+// 個々の SQL 文の整合性は SQLite 自身が保証します。
+func (s *sqliteTaskStore) Lock() (func(), error) {
+	return acquireLock(sidecarPath(s.path, sidecarLock))
+}
+
+func (s *sqliteTaskStore) Path() string {
+	return s.path
+}
+
+func (s *sqliteTaskStore) ReplaceAll(tasks []Task) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM tasks`); err != nil {
+		return err
+	}
+	for _, t := range tasks {
+		data, err := json.Marshal(t)
+		if err != nil {
+			return err
+		}
+		if _, err := tx.Exec(`INSERT INTO tasks (id, data) VALUES (?, ?)`, t.ID, string(data)); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func (s *sqliteTaskStore) ListSprints() ([]Sprint, error) {
+	rows, err := s.db.Query(`SELECT data FROM sprints ORDER BY number`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	sprints := []Sprint{}
+	for rows.Next() {
+		var data string
+		if err := rows.Scan(&data); err != nil {
+			return nil, err
+		}
+		var sp Sprint
+		if err := json.Unmarshal([]byte(data), &sp); err != nil {
+			return nil, err
+		}
+		sprints = append(sprints, sp)
+	}
+	return sprints, rows.Err()
+}
+
+func (s *sqliteTaskStore) GetSprint(number int) (Sprint, error) {
+	var data string
+	err := s.db.QueryRow(`SELECT data FROM sprints WHERE number = ?`, number).Scan(&data)
+	if err == sql.ErrNoRows {
+		return Sprint{}, ErrSprintNotFound
+	}
+	if err != nil {
+		return Sprint{}, err
+	}
+	var sp Sprint
+	if err := json.Unmarshal([]byte(data), &sp); err != nil {
+		return Sprint{}, err
+	}
+	return sp, nil
+}
+
+func (s *sqliteTaskStore) SaveSprint(sprint Sprint) error {
+	data, err := json.Marshal(sprint)
+	if err != nil {
+		return err
+	}
+	_, err = s.db.Exec(`INSERT INTO sprints (number, data) VALUES (?, ?)
+		ON CONFLICT(number) DO UPDATE SET data = excluded.data`, sprint.Number, string(data))
+	return err
+}
+
+func (s *sqliteTaskStore) ListEpics() ([]Epic, error) {
+	rows, err := s.db.Query(`SELECT data FROM epics ORDER BY id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	epics := []Epic{}
+	for rows.Next() {
+		var data string
+		if err := rows.Scan(&data); err != nil {
+			return nil, err
+		}
+		var e Epic
+		if err := json.Unmarshal([]byte(data), &e); err != nil {
+			return nil, err
+		}
+		epics = append(epics, e)
+	}
+	return epics, rows.Err()
+}
+
+func (s *sqliteTaskStore) GetEpic(id int) (Epic, error) {
+	var data string
+	err := s.db.QueryRow(`SELECT data FROM epics WHERE id = ?`, id).Scan(&data)
+	if err == sql.ErrNoRows {
+		return Epic{}, ErrEpicNotFound
+	}
+	if err != nil {
+		return Epic{}, err
+	}
+	var e Epic
+	if err := json.Unmarshal([]byte(data), &e); err != nil {
+		return Epic{}, err
+	}
+	return e, nil
+}
+
+func (s *sqliteTaskStore) SaveEpic(epic Epic) error {
+	data, err := json.Marshal(epic)
+	if err != nil {
+		return err
+	}
+	_, err = s.db.Exec(`INSERT INTO epics (id, data) VALUES (?, ?)
+		ON CONFLICT(id) DO UPDATE SET data = excluded.data`, epic.ID, string(data))
+	return err
+}
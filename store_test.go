@@ -0,0 +1,211 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// storeBackends は JSON と SQLite の保存先を一時ディレクトリに作ります。各テストは両方で同じ確認をします。
+var storeBackends = []struct {
+	name string
+	open func(t *testing.T) TaskStore
+}{
+	{"json", func(t *testing.T) TaskStore {
+		return &jsonTaskStore{path: filepath.Join(t.TempDir(), dataFile)}
+	}},
+	{"sqlite", func(t *testing.T) TaskStore {
+		s, err := newSQLiteTaskStore(filepath.Join(t.TempDir(), sqliteDataFile))
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { s.db.Close() })
+		return s
+	}},
+}
+
+func TestStoreCRUD(t *testing.T) {
+	for _, b := range storeBackends {
+		t.Run(b.name, func(t *testing.T) {
+			s := b.open(t)
+			if tasks, err := s.List(); err != nil || len(tasks) != 0 {
+				t.Fatalf("空の保存先の List() = %v, %v", tasks, err)
+			}
+
+			a, err := s.Create(Task{Title: "a", Status: "todo", TaskWeight: 3, Assignees: AssigneeList{}})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := s.Create(Task{Title: "b", Status: "todo"}); err != nil {
+				t.Fatal(err)
+			}
+			got, err := s.Get(a.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !sameJSON(got, a) {
+				t.Errorf("Get(%d) = %+v, want %+v", a.ID, got, a)
+			}
+
+			a.Title, a.Assignees = "a2", AssigneeList{{Name: "hanako", Share: 2}}
+			if err := s.Update(a); err != nil {
+				t.Fatal(err)
+			}
+			if got, _ := s.Get(a.ID); got.Title != "a2" || got.Assignees.String() != "hanako(2)" {
+				t.Errorf("Update 後の #%d = %q %s", a.ID, got.Title, got.Assignees)
+			}
+
+			if err := s.Delete(a.ID); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := s.Get(a.ID); err != ErrTaskNotFound {
+				t.Errorf("削除後の Get() の err = %v, want ErrTaskNotFound", err)
+			}
+			if err := s.Delete(a.ID); err != ErrTaskNotFound {
+				t.Errorf("存在しないタスクの Delete() の err = %v, want ErrTaskNotFound", err)
+			}
+			if err := s.Update(Task{ID: 99, Title: "x"}); err != ErrTaskNotFound {
+				t.Errorf("存在しないタスクの Update() の err = %v, want ErrTaskNotFound", err)
+			}
+		})
+	}
+}
+
+func TestStoreIDAllocation(t *testing.T) {
+	for _, b := range storeBackends {
+		t.Run(b.name, func(t *testing.T) {
+			s := b.open(t)
+			for i := 1; i <= 3; i++ {
+				task, err := s.Create(Task{Title: "t", Status: "todo"})
+				if err != nil {
+					t.Fatal(err)
+				}
+				if task.ID != i {
+					t.Fatalf("%d 件目の ID = %d, want %d", i, task.ID, i)
+				}
+			}
+			// 途中の ID が欠けていても、最大の ID の次を使う
+			if err := s.Delete(2); err != nil {
+				t.Fatal(err)
+			}
+			task, err := s.Create(Task{Title: "t", Status: "todo"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if task.ID != 4 {
+				t.Errorf("#2 を削除した後の ID = %d, want 4", task.ID)
+			}
+		})
+	}
+}
+
+func TestStoreReplaceAll(t *testing.T) {
+	for _, b := range storeBackends {
+		t.Run(b.name, func(t *testing.T) {
+			s := b.open(t)
+			if _, err := s.Create(Task{Title: "old", Status: "todo"}); err != nil {
+				t.Fatal(err)
+			}
+			want := []Task{
+				{ID: 5, Title: "e", Status: "doing", Assignees: AssigneeList{{Name: "taro"}}},
+				{ID: 9, Title: "i", Status: "done", SprintNumber: 2, TaskWeight: 8, Assignees: AssigneeList{}},
+			}
+			if err := s.ReplaceAll(want); err != nil {
+				t.Fatal(err)
+			}
+			got, err := s.List()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !sameJSON(got, want) {
+				t.Errorf("List() = %+v, want %+v", got, want)
+			}
+			task, err := s.Create(Task{Title: "next", Status: "todo"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if task.ID != 10 {
+				t.Errorf("ReplaceAll 後の ID = %d, want 10", task.ID)
+			}
+		})
+	}
+}
+
+func TestStoreSprintsAndEpics(t *testing.T) {
+	for _, b := range storeBackends {
+		t.Run(b.name, func(t *testing.T) {
+			s := b.open(t)
+			if _, err := s.GetSprint(1); err != ErrSprintNotFound {
+				t.Errorf("GetSprint() の err = %v, want ErrSprintNotFound", err)
+			}
+			for _, sp := range []Sprint{{Number: 2, State: SprintPlanned}, {Number: 1, State: SprintActive}} {
+				if err := s.SaveSprint(sp); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err := s.SaveSprint(Sprint{Number: 2, State: SprintPlanned, Goal: "release"}); err != nil {
+				t.Fatal(err)
+			}
+			sprints, err := s.ListSprints()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(sprints) != 2 || sprints[0].Number != 1 || sprints[1].Goal != "release" {
+				t.Errorf("ListSprints() = %+v", sprints)
+			}
+
+			if _, err := s.GetEpic(1); err != ErrEpicNotFound {
+				t.Errorf("GetEpic() の err = %v, want ErrEpicNotFound", err)
+			}
+			if err := s.SaveEpic(Epic{ID: 1, Title: "login"}); err != nil {
+				t.Fatal(err)
+			}
+			if e, err := s.GetEpic(1); err != nil || e.Title != "login" {
+				t.Errorf("GetEpic(1) = %+v, %v", e, err)
+			}
+		})
+	}
+}
+
+func TestStoreLock(t *testing.T) {
+	for _, b := range storeBackends {
+		t.Run(b.name, func(t *testing.T) {
+			s := b.open(t)
+			unlock, err := s.Lock()
+			if err != nil {
+				t.Fatal(err)
+			}
+			locked := make(chan func())
+			go func() {
+				unlock2, err := s.Lock()
+				if err != nil {
+					t.Error(err)
+				}
+				locked <- unlock2
+			}()
+			select {
+			case <-locked:
+				t.Fatal("ロック中に2つ目の Lock() が取得できました")
+			case <-time.After(100 * time.Millisecond):
+			}
+			unlock()
+			select {
+			case unlock2 := <-locked:
+				unlock2()
+			case <-time.After(5 * time.Second):
+				t.Fatal("ロックを解放しても2つ目の Lock() が取得できません")
+			}
+		})
+	}
+}
+
+func TestSidecarPathsDoNotCollide(t *testing.T) {
+	jsonPath, dbPath := sidecarPath(dataFile, sidecarHistory), sidecarPath(sqliteDataFile, sidecarHistory)
+	if jsonPath == dbPath {
+		t.Errorf("todo.json と todo.db の履歴ファイルが同じです: %s", jsonPath)
+	}
+	js := &jsonTaskStore{path: "x.db"}
+	if got := js.sprintsPath(); got != "x.db.sprints.json" {
+		t.Errorf("sprintsPath() = %q, want x.db.sprints.json", got)
+	}
+}
@@ -0,0 +1,580 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+// sprintTimer はスプリントタイマーの状態（実行中のフェーズ・経過時間・一時停止）を管理します。
+// 経過時間は時計から求めるため、表示の更新間隔や一時停止の回数によってずれることはありません。
+// Pause / Resume / Skip は入力を受け付けるゴルーチンから、Run はタイマーのゴルーチンから呼びます。
+type sprintTimer struct {
+	mu      sync.Mutex
+	phases  []timerPhase
+	current int           // 実行中のフェーズ（len(phases) ですべて終了）
+	elapsed time.Duration // 現在のフェーズで、最後に一時停止するまでに経過した時間
+	since   time.Time     // 最後に開始・再開した時刻（一時停止中はゼロ値）
+	changed chan struct{} // 状態が変わったことを Run に知らせる
+}
+
+type timerPhase struct {
+	Name    string
+	Planned time.Duration // extend で延長した時間を含む
+	Actual  time.Duration // 終了したフェーズの実際の時間（一時停止中を除く）
+	Skipped bool
+}
+
+// activeTimer は実行中のスプリントタイマーです（timerstart の実行中のみ）。
+// pause / resume / extend / skip / status コマンドはこれを操作します。
+var activeTimer *sprintTimer
+
+// timerStatus はある時点のタイマーの状態です。
+type timerStatus struct {
+	Phase   int // 0 始まり
+	Phases  int
+	Name    string
+	Elapsed time.Duration
+	Planned time.Duration
+	Paused  bool
+	Done    bool // すべてのフェーズが終了した
+}
+
+func newSprintTimer(settings *Timer) *sprintTimer {
+	return &sprintTimer{
+		phases: []timerPhase{
+			{Name: "スプリント計画", Planned: time.Duration(settings.Plannning) * time.Minute},
+			{Name: "開発", Planned: time.Duration(settings.Development) * time.Minute},
+			{Name: "スプリントレビュー＋振り返り", Planned: time.Duration(settings.Review) * time.Minute},
+		},
+		changed: make(chan struct{}, 1),
+	}
+}
+
+func (t *sprintTimer) notify() {
+	select {
+	case t.changed <- struct{}{}:
+	default:
+	}
+}
+
+// Pause はタイマーを一時停止します。
+func (t *sprintTimer) Pause() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.current >= len(t.phases) {
+		return fmt.Errorf("タイマーは終了しています")
+	}
+	if t.since.IsZero() {
+		return fmt.Errorf("タイマーは一時停止中です")
+	}
+	t.elapsed += time.Since(t.since)
+	t.since = time.Time{}
+	t.notify()
+	return nil
+}
+
+// Resume は一時停止したタイマーを再開します。
+func (t *sprintTimer) Resume() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.current >= len(t.phases) {
+		return fmt.Errorf("タイマーは終了しています")
+	}
+	if !t.since.IsZero() {
+		return fmt.Errorf("タイマーは一時停止していません")
+	}
+	t.since = time.Now()
+	t.notify()
+	return nil
+}
+
+// Extend は現在のフェーズの予定時間を延長します。
+func (t *sprintTimer) Extend(d time.Duration) (timerPhase, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.current >= len(t.phases) {
+		return timerPhase{}, fmt.Errorf("タイマーは終了しています")
+	}
+	t.phases[t.current].Planned += d
+	t.notify()
+	return t.phases[t.current], nil
+}
+
+// Skip は現在のフェーズを終了し、次のフェーズを始めます（一時停止中の場合は再開します）。
+func (t *sprintTimer) Skip() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.current >= len(t.phases) {
+		return fmt.Errorf("タイマーは終了しています")
+	}
+	t.advance(true)
+	t.notify()
+	return nil
+}
+
+// advance は現在のフェーズの実績を記録して次のフェーズへ進めます。t.mu を取得した状態で呼びます。
+func (t *sprintTimer) advance(skipped bool) {
+	p := &t.phases[t.current]
+	p.Actual, p.Skipped = t.currentElapsed(), skipped
+	t.current++
+	t.elapsed = 0
+	t.since = time.Now()
+}
+
+// currentElapsed は現在のフェーズの経過時間です。t.mu を取得した状態で呼びます。
+func (t *sprintTimer) currentElapsed() time.Duration {
+	if t.since.IsZero() {
+		return t.elapsed
+	}
+	return t.elapsed + time.Since(t.since)
+}
+
+// Phases は各フェーズの予定と実績を返します。実行中のフェーズの Actual はその時点までの経過時間です。
+func (t *sprintTimer) Phases() []timerPhase {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	phases := append([]timerPhase(nil), t.phases...)
+	if t.current < len(phases) {
+		phases[t.current].Actual = t.currentElapsed()
+	}
+	return phases
+}
+
+// Status は現在の状態を返します。
+func (t *sprintTimer) Status() timerStatus {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	st := timerStatus{Phase: t.current, Phases: len(t.phases)}
+	if t.current >= len(t.phases) {
+		st.Done = true
+		return st
+	}
+	p := t.phases[t.current]
+	st.Name, st.Planned, st.Elapsed, st.Paused = p.Name, p.Planned, t.currentElapsed(), t.since.IsZero()
+	return st
+}
+
+// Run はすべてのフェーズが終わるか ctx が取り消されるまでタイマーを進め、終わった場合は true を返します。
+// onStart・onEnd はフェーズの開始・終了時、onTick は約1秒ごとと状態が変わったときに Run のゴルーチンで呼ばれます。
+func (t *sprintTimer) Run(ctx context.Context, onStart, onEnd func(phase int), onTick func(timerStatus)) bool {
+	t.mu.Lock()
+	t.since = time.Now()
+	t.mu.Unlock()
+	seen := 0
+	onStart(0)
+
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+	for {
+		st := t.Status()
+		if !st.Done && !st.Paused && st.Elapsed >= st.Planned {
+			t.mu.Lock()
+			if t.current == st.Phase { // 同時に Skip された場合は進めない
+				t.advance(false)
+			}
+			t.mu.Unlock()
+			st = t.Status()
+		}
+		for ; seen < st.Phase; seen++ {
+			onEnd(seen)
+			if seen+1 < st.Phases {
+				onStart(seen + 1)
+			}
+		}
+		if st.Done {
+			return true
+		}
+		onTick(st)
+
+		select {
+		case <-ctx.Done():
+			return false
+		case <-ticker.C:
+		case <-t.changed:
+		}
+	}
+}
+
+// formatClock は時間を mm:ss 形式にします。
+func formatClock(d time.Duration) string {
+	sec := int(d.Round(time.Second) / time.Second)
+	return fmt.Sprintf("%02d:%02d", sec/60, sec%60)
+}
+
+// progressBar は経過の割合を width 文字のバーにします。予定を超えた分は満杯として表示します。
+func progressBar(elapsed, planned time.Duration, width int) string {
+	filled := width
+	if planned > 0 && elapsed < planned {
+		filled = int(int64(width) * int64(elapsed) / int64(planned))
+	}
+	if filled < 0 {
+		filled = 0
+	}
+	return strings.Repeat("█", filled) + strings.Repeat("░", width-filled)
+}
+
+// finishSprintRun はタイマーの終了時（すべてのフェーズの終了、または exit）に、各フェーズの予定と実績を
+// timer_setting.json の実行記録に保存します。スプリント自体は sprint close で終了するため、スプリント番号は変えません。
+// 実行中に timersetting で変更された設定を上書きしないよう、ファイルを読み込み直してから保存します。
+func finishSprintRun(number int, timer *sprintTimer, completed bool) error {
+	unlock, err := acquireLock(timersettingFile + ".lock")
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	settings, err := loadTimerSettings()
+	if err != nil {
+		return err
+	}
+	if settings == nil {
+		settings = &Timer{SprintNumber: number}
+	}
+	if settings.Runs == nil {
+		settings.Runs = make(map[int]SprintRun)
+	}
+
+	run := settings.Runs[number]
+	run.Phases = nil
+	for _, p := range timer.Phases() {
+		run.Phases = append(run.Phases, PhaseRun{
+			Name:    p.Name,
+			Planned: int(p.Planned / time.Minute),
+			Actual:  int(p.Actual.Round(time.Second) / time.Second),
+			Skipped: p.Skipped,
+		})
+	}
+	run.EndedAt = timePtr(time.Now())
+	run.State = "exited"
+	if completed {
+		run.State = "completed"
+	}
+	settings.Runs[number] = run
+	return saveTimerSettings(settings)
+}
+
+// ==== タイマーの操作（スプリントコンソールと TUI のコマンド） ==================
+
+func runningTimer() (*sprintTimer, error) {
+	if activeTimer == nil {
+		return nil, fmt.Errorf("スプリントタイマーが動いていません（timerstart で開始してください）")
+	}
+	return activeTimer, nil
+}
+
+// PauseTimer はスプリントタイマーを一時停止します。
+func PauseTimer() error {
+	t, err := runningTimer()
+	if err != nil {
+		return err
+	}
+	if err := t.Pause(); err != nil {
+		return err
+	}
+	output.Printf("タイマーを一時停止しました（resume で再開）")
+	return nil
+}
+
+// ResumeTimer は一時停止したスプリントタイマーを再開します。
+func ResumeTimer() error {
+	t, err := runningTimer()
+	if err != nil {
+		return err
+	}
+	if err := t.Resume(); err != nil {
+		return err
+	}
+	output.Printf("タイマーを再開しました")
+	return nil
+}
+
+// ExtendTimer は現在のフェーズを minutes 分延長します。
+func ExtendTimer(minutes int) error {
+	t, err := runningTimer()
+	if err != nil {
+		return err
+	}
+	p, err := t.Extend(time.Duration(minutes) * time.Minute)
+	if err != nil {
+		return err
+	}
+	output.Printf("%s を %d 分延長しました（予定 %d 分）", p.Name, minutes, int(p.Planned/time.Minute))
+	return nil
+}
+
+// SkipPhase は現在のフェーズを終了し、次のフェーズへ進めます。
+func SkipPhase() error {
+	t, err := runningTimer()
+	if err != nil {
+		return err
+	}
+	name := t.Status().Name
+	if err := t.Skip(); err != nil {
+		return err
+	}
+	output.Printf("%s を終了しました", name)
+	return nil
+}
+
+// ShowTimerStatus は現在のフェーズの残り時間と、各フェーズの予定・実績を表示します。
+func ShowTimerStatus() error {
+	t, err := runningTimer()
+	if err != nil {
+		return err
+	}
+	st := t.Status()
+	if st.Done {
+		output.Printf("すべてのフェーズが終了しました")
+	} else {
+		state := "実行中"
+		if st.Paused {
+			state = "一時停止中"
+		}
+		remaining := st.Planned - st.Elapsed
+		if remaining < 0 {
+			remaining = 0
+		}
+		output.Printf("フェーズ %d/%d: %s（%s） 残り %s", st.Phase+1, st.Phases, st.Name, state, formatClock(remaining))
+	}
+
+	table := &Table{Header: []string{"Phase", "Planned", "Actual", "State"}}
+	for i, p := range t.Phases() {
+		state := "pending"
+		switch {
+		case p.Skipped:
+			state = "skipped"
+		case i < st.Phase:
+			state = "done"
+		case i == st.Phase && st.Paused:
+			state = "paused"
+		case i == st.Phase:
+			state = "running"
+		}
+		actual := "-"
+		if i <= st.Phase {
+			actual = formatClock(p.Actual)
+		}
+		table.Append([]string{p.Name, formatClock(p.Planned), actual, state})
+	}
+	output.AddTable(table)
+	return nil
+}
+
+// ==== TUI =====================================================================
+
+const timerTUIHelp = "[yellow]Ctrl+P[-] 一時停止/再開（pause / resume）  [yellow]Ctrl+N[-] 次のフェーズへ（skip）  " +
+	"[yellow]status[-] 予定と実績  [yellow]exit[-] 終了"
+
+// TimerStartSprintTUI はスプリントタイマーを全画面で表示します。フェーズの進み具合と
+// スプリントのタスク（Todo / Doing / Done）を表示し、スプリントコンソールと同じコマンドを受け付けます。
+func TimerStartSprintTUI(label string) error {
+	settings, err := loadTimerSettings()
+	if err != nil {
+		return err
+	}
+	number, err := currentSprint()
+	if err != nil {
+		return err
+	}
+	if settings == nil {
+		settings = &Timer{
+			Plannning:    15,
+			Development:  60,
+			Review:       15,
+			SprintNumber: number,
+		}
+	} else {
+		settings.SprintNumber = number
+	}
+	if err := recordSprintRun(settings); err != nil {
+		return err
+	}
+	timer := newSprintTimer(settings)
+
+	app := tview.NewApplication()
+	progress := tview.NewTextView().SetDynamicColors(true)
+	progress.SetBorder(true).SetTitle(fmt.Sprintf(" スプリント %d ", settings.SprintNumber))
+	panes := []*tview.TextView{}
+	paneRow := tview.NewFlex()
+	for i := 0; i < 3; i++ {
+		pane := tview.NewTextView().SetDynamicColors(true)
+		pane.SetBorder(true)
+		panes = append(panes, pane)
+		paneRow.AddItem(pane, 0, 1, false)
+	}
+	messages := tview.NewTextView().SetDynamicColors(true).SetScrollable(true).SetChangedFunc(func() {
+		app.Draw()
+	})
+	input := tview.NewInputField().SetLabel("Command > ")
+	help := tview.NewTextView().SetDynamicColors(true).SetText(timerTUIHelp)
+
+	layout := tview.NewFlex().SetDirection(tview.FlexRow).
+		AddItem(progress, 4, 0, false).
+		AddItem(paneRow, 0, 2, false).
+		AddItem(messages, 0, 1, false).
+		AddItem(input, 1, 0, true).
+		AddItem(help, 1, 0, false)
+
+	refreshPanes := func() {
+		tasks, all, err := sprintTasks(settings.SprintNumber, label)
+		if err != nil {
+			writeResultTUI(messages, &Result{Err: err})
+			return
+		}
+		writeTaskPanes(panes, tasks, tasksByID(all))
+	}
+	showProgress := func(st timerStatus) {
+		progress.SetText(formatTimerStatus(st))
+	}
+	input.SetDoneFunc(func(key tcell.Key) {
+		if key != tcell.KeyEnter {
+			return
+		}
+		cmd := strings.TrimSpace(input.GetText())
+		input.SetText("")
+		if cmd == "exit" {
+			app.Stop()
+			return
+		}
+		handleTUIViewCommand(cmd, messages)
+		messages.ScrollToEnd()
+		refreshPanes()
+	})
+	app.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		switch event.Key() {
+		case tcell.KeyCtrlP:
+			if timer.Status().Paused {
+				handleTUIViewCommand("resume", messages)
+			} else {
+				handleTUIViewCommand("pause", messages)
+			}
+			return nil
+		case tcell.KeyCtrlN:
+			handleTUIViewCommand("skip", messages)
+			return nil
+		}
+		return event
+	})
+
+	activeTimer = timer
+	defer func() { activeTimer = nil }()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	// タイマーの終了（すべてのフェーズの終了または exit）を一度だけ記録する
+	var finishOnce sync.Once
+	var finishErr error
+	finish := func(completed bool) error {
+		finishOnce.Do(func() { finishErr = finishSprintRun(settings.SprintNumber, timer, completed) })
+		return finishErr
+	}
+	go func() {
+		onStart := func(phase int) {
+			app.QueueUpdateDraw(func() {
+				p := timer.Phases()[phase]
+				fmt.Fprintf(messages, "[green]%s（%d分）を開始します[-]\n", p.Name, int(p.Planned/time.Minute))
+				refreshPanes()
+			})
+		}
+		onEnd := func(phase int) {
+			app.QueueUpdateDraw(func() {
+				fmt.Fprintf(messages, "[green]%s が終了しました[-]\n", timer.Phases()[phase].Name)
+			})
+		}
+		onTick := func(st timerStatus) {
+			app.QueueUpdateDraw(func() { showProgress(st) })
+		}
+		if !timer.Run(ctx, onStart, onEnd, onTick) {
+			return
+		}
+		err := finish(true)
+		app.QueueUpdateDraw(func() {
+			showProgress(timer.Status())
+			if err != nil {
+				fmt.Fprintf(messages, "[red]実績を記録できませんでした: %s[-]\n", tview.Escape(err.Error()))
+			}
+			fmt.Fprintln(messages, "[blue]=== スプリントタイムボックス終了 ===[-]")
+			fmt.Fprintf(messages, "未完了のタスクは sprint close %d --unfinished carry|backlog|drop で持ち越し・バックログへの戻しができます\n", settings.SprintNumber)
+		})
+	}()
+
+	refreshPanes()
+	showProgress(timer.Status())
+	if err := app.SetRoot(layout, true).EnableMouse(true).Run(); err != nil {
+		return fmt.Errorf("タイマーを表示できませんでした: %w", err)
+	}
+	cancel()
+	// exit で途中終了した場合も実績を記録する
+	if err := finish(timer.Status().Done); err != nil {
+		return fmt.Errorf("実績を記録できませんでした: %w", err)
+	}
+	return nil
+}
+
+// formatTimerStatus はフェーズ名・残り時間・進捗バーを表示用のテキストにします。
+func formatTimerStatus(st timerStatus) string {
+	if st.Done {
+		return "[blue]すべてのフェーズが終了しました[-]\n" + progressBar(1, 1, 50)
+	}
+	color, state := "green", ""
+	if st.Paused {
+		color, state = "yellow", "（一時停止中）"
+	}
+	remaining := st.Planned - st.Elapsed
+	if remaining < 0 {
+		remaining = 0
+	}
+	return fmt.Sprintf("[%s]フェーズ %d/%d: %s%s  残り %s（%s / %s）[-]\n[%s]%s[-]",
+		color, st.Phase+1, st.Phases, st.Name, state, formatClock(remaining), formatClock(st.Elapsed), formatClock(st.Planned),
+		color, progressBar(st.Elapsed, st.Planned, 50))
+}
+
+// writeTaskPanes はスプリントのタスクを Todo（最初のステータス）・Doing（作業中のステータス）・
+// Done（完了）の3つのペインに表示します。Doing には workflow.Start 以外の途中のステータス（review など）も含めます。
+func writeTaskPanes(panes []*tview.TextView, tasks []Task, byID map[int]Task) {
+	groups := make([][]Task, 3)
+	for _, t := range tasks {
+		switch {
+		case t.Status == workflow.Initial:
+			groups[0] = append(groups[0], t)
+		case t.IsDone():
+			groups[2] = append(groups[2], t)
+		default:
+			groups[1] = append(groups[1], t)
+		}
+	}
+	titles := []string{workflow.Initial, workflow.Start, workflow.Done}
+	for i, pane := range panes {
+		pane.SetTitle(fmt.Sprintf(" %s (%d) ", titles[i], len(groups[i])))
+		pane.Clear()
+		for _, t := range groups[i] {
+			line := cardTitle(t, byID)
+			if i == 1 && t.Status != workflow.Start {
+				line += " [gray](" + tview.Escape(t.Status) + ")[-]"
+			}
+			fmt.Fprintln(pane, line)
+			fmt.Fprintln(pane, cardDetail(t))
+		}
+	}
+}
+
+// handleTUIViewCommand は TUI に入力されたコマンドを CLI と同じ表（commands.go）で実行し、結果を表示します。
+func handleTUIViewCommand(cmd string, out io.Writer) {
+	line := strings.TrimSpace(cmd)
+	if line == "" {
+		return
+	}
+	fmt.Fprintln(out, "[::b]> "+tview.Escape(line)+"[::-]")
+	res := executeLine(line, frontendTUI, nil)
+	writeResultTUI(out, res)
+	if res.Err != nil {
+		fmt.Fprintln(out, "[red]失敗: "+tview.Escape(line))
+	} else {
+		fmt.Fprintln(out, "[green]完了: "+tview.Escape(line))
+	}
+}
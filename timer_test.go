@@ -0,0 +1,370 @@
+package main
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rivo/tview"
+)
+
+// newTestTimer は予定時間が planned のフェーズを持つタイマーを作ります。
+func newTestTimer(planned ...time.Duration) *sprintTimer {
+	t := &sprintTimer{changed: make(chan struct{}, 1)}
+	for i, d := range planned {
+		t.phases = append(t.phases, timerPhase{Name: string(rune('A' + i)), Planned: d})
+	}
+	return t
+}
+
+// start は Run を呼ばずに最初のフェーズを開始した状態にします。
+func (t *sprintTimer) start() {
+	t.mu.Lock()
+	t.since = time.Now()
+	t.mu.Unlock()
+}
+
+func TestNewSprintTimer(t *testing.T) {
+	timer := newSprintTimer(&Timer{Plannning: 10, Development: 20, Review: 30})
+	phases := timer.Phases()
+	if len(phases) != 3 {
+		t.Fatalf("フェーズ数 = %d, want 3", len(phases))
+	}
+	for i, want := range []time.Duration{10 * time.Minute, 20 * time.Minute, 30 * time.Minute} {
+		if phases[i].Planned != want {
+			t.Errorf("phases[%d].Planned = %v, want %v", i, phases[i].Planned, want)
+		}
+	}
+	if st := timer.Status(); st.Phase != 0 || !st.Paused || st.Done {
+		t.Errorf("開始前の状態 = %+v", st)
+	}
+}
+
+func TestTimerPauseResume(t *testing.T) {
+	timer := newTestTimer(time.Hour)
+	timer.start()
+
+	if err := timer.Resume(); err == nil {
+		t.Error("実行中の Resume がエラーになりません")
+	}
+	if err := timer.Pause(); err != nil {
+		t.Fatal(err)
+	}
+	if err := timer.Pause(); err == nil {
+		t.Error("一時停止中の Pause がエラーになりません")
+	}
+
+	// 一時停止中は経過時間が進まない
+	paused := timer.Status()
+	if !paused.Paused {
+		t.Fatalf("Pause 後の状態 = %+v", paused)
+	}
+	time.Sleep(20 * time.Millisecond)
+	if got := timer.Status().Elapsed; got != paused.Elapsed {
+		t.Errorf("一時停止中に経過時間が %v から %v に進みました", paused.Elapsed, got)
+	}
+
+	if err := timer.Resume(); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(20 * time.Millisecond)
+	if st := timer.Status(); st.Paused || st.Elapsed <= paused.Elapsed {
+		t.Errorf("Resume 後の状態 = %+v", st)
+	}
+}
+
+func TestTimerExtend(t *testing.T) {
+	timer := newTestTimer(10*time.Minute, 20*time.Minute)
+	timer.start()
+
+	p, err := timer.Extend(5 * time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Name != "A" || p.Planned != 15*time.Minute {
+		t.Errorf("Extend() = %s %v, want A 15m", p.Name, p.Planned)
+	}
+	if got := timer.Status().Planned; got != 15*time.Minute {
+		t.Errorf("延長後の予定 = %v, want 15m", got)
+	}
+	if got := timer.Phases()[1].Planned; got != 20*time.Minute {
+		t.Errorf("次のフェーズの予定 = %v, want 20m", got)
+	}
+}
+
+func TestTimerSkip(t *testing.T) {
+	timer := newTestTimer(time.Hour, time.Hour)
+	timer.start()
+	time.Sleep(20 * time.Millisecond)
+	if err := timer.Pause(); err != nil {
+		t.Fatal(err)
+	}
+
+	// 一時停止中に Skip すると実績を記録し、次のフェーズを再開した状態で始める
+	if err := timer.Skip(); err != nil {
+		t.Fatal(err)
+	}
+	st := timer.Status()
+	if st.Phase != 1 || st.Name != "B" || st.Paused {
+		t.Errorf("Skip 後の状態 = %+v", st)
+	}
+	first := timer.Phases()[0]
+	if !first.Skipped || first.Actual < 20*time.Millisecond {
+		t.Errorf("スキップしたフェーズ = %+v", first)
+	}
+
+	if err := timer.Skip(); err != nil {
+		t.Fatal(err)
+	}
+	if st := timer.Status(); !st.Done || st.Phase != 2 {
+		t.Errorf("最後のフェーズの Skip 後の状態 = %+v", st)
+	}
+}
+
+func TestTimerDoneRejectsOperations(t *testing.T) {
+	timer := newTestTimer(time.Hour)
+	timer.start()
+	if err := timer.Skip(); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := timer.Pause(); err == nil {
+		t.Error("終了後の Pause がエラーになりません")
+	}
+	if err := timer.Resume(); err == nil {
+		t.Error("終了後の Resume がエラーになりません")
+	}
+	if _, err := timer.Extend(time.Minute); err == nil {
+		t.Error("終了後の Extend がエラーになりません")
+	}
+	if err := timer.Skip(); err == nil {
+		t.Error("終了後の Skip がエラーになりません")
+	}
+}
+
+func TestTimerRunCompletes(t *testing.T) {
+	timer := newTestTimer(10*time.Millisecond, 10*time.Millisecond)
+	var events []string
+	onStart := func(phase int) { events = append(events, "start "+timer.phases[phase].Name) }
+	onEnd := func(phase int) { events = append(events, "end "+timer.phases[phase].Name) }
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if !timer.Run(ctx, onStart, onEnd, func(timerStatus) {}) {
+		t.Fatal("Run() = false, want true")
+	}
+	want := []string{"start A", "end A", "start B", "end B"}
+	if len(events) != len(want) {
+		t.Fatalf("events = %v, want %v", events, want)
+	}
+	for i := range want {
+		if events[i] != want[i] {
+			t.Errorf("events = %v, want %v", events, want)
+			break
+		}
+	}
+	for _, p := range timer.Phases() {
+		if p.Skipped || p.Actual < p.Planned {
+			t.Errorf("フェーズ %s = %+v", p.Name, p)
+		}
+	}
+}
+
+func TestTimerRunCanceled(t *testing.T) {
+	timer := newTestTimer(time.Hour)
+	ctx, cancel := context.WithCancel(context.Background())
+	ticked := make(chan struct{}, 1)
+	go func() {
+		<-ticked
+		cancel()
+	}()
+
+	onTick := func(timerStatus) {
+		select {
+		case ticked <- struct{}{}:
+		default:
+		}
+	}
+	if timer.Run(ctx, func(int) {}, func(int) {}, onTick) {
+		t.Error("取り消された Run() = true, want false")
+	}
+	if timer.Status().Done {
+		t.Error("取り消されたタイマーが終了しています")
+	}
+}
+
+func TestFinishSprintRun(t *testing.T) {
+	tests := []struct {
+		name       string
+		completed  bool
+		wantState  string
+		wantNumber int
+	}{
+		{"すべてのフェーズが終了", true, "completed", 3}, // スプリントは sprint close で終える
+		{"途中で exit", false, "exited", 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setupStore(t)
+			settings := &Timer{Plannning: 10, Development: 20, Review: 30, SprintNumber: 3}
+			if err := recordSprintRun(settings); err != nil {
+				t.Fatal(err)
+			}
+
+			timer := newSprintTimer(settings)
+			timer.start()
+			if _, err := timer.Extend(5 * time.Minute); err != nil {
+				t.Fatal(err)
+			}
+			if err := timer.Skip(); err != nil {
+				t.Fatal(err)
+			}
+			if err := finishSprintRun(3, timer, tt.completed); err != nil {
+				t.Fatal(err)
+			}
+
+			saved, err := loadTimerSettings()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if saved.SprintNumber != tt.wantNumber {
+				t.Errorf("スプリント番号 = %d, want %d", saved.SprintNumber, tt.wantNumber)
+			}
+			run := saved.Runs[3]
+			if run.State != tt.wantState || run.EndedAt == nil {
+				t.Errorf("実行記録 = %s, ended_at %v, want %s", run.State, run.EndedAt, tt.wantState)
+			}
+			if len(run.Phases) != 3 {
+				t.Fatalf("フェーズ数 = %d, want 3", len(run.Phases))
+			}
+			if p := run.Phases[0]; p.Planned != 15 || !p.Skipped {
+				t.Errorf("スプリント計画 = %+v, want 延長後の予定 15 分でスキップ", p)
+			}
+			if p := run.Phases[2]; p.Planned != 30 || p.Skipped {
+				t.Errorf("スプリントレビュー＋振り返り = %+v", p)
+			}
+		})
+	}
+}
+
+func TestRecordSprintRunKeepsFirstStart(t *testing.T) {
+	setupStore(t)
+	settings := &Timer{Plannning: 10, Development: 20, Review: 30, SprintNumber: 1}
+	if err := recordSprintRun(settings); err != nil {
+		t.Fatal(err)
+	}
+	first, err := loadTimerSettings()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 同じスプリントでタイマーを再開しても開始日時は変わらず、フェーズの時間は更新する
+	time.Sleep(10 * time.Millisecond)
+	settings.Development = 40
+	if err := recordSprintRun(settings); err != nil {
+		t.Fatal(err)
+	}
+	second, err := loadTimerSettings()
+	if err != nil {
+		t.Fatal(err)
+	}
+	before, after := first.Runs[1], second.Runs[1]
+	if !after.StartedAt.Equal(before.StartedAt) {
+		t.Errorf("開始日時 = %v, want %v", after.StartedAt, before.StartedAt)
+	}
+	if after.Development != 40 {
+		t.Errorf("開発 = %d 分, want 40", after.Development)
+	}
+}
+
+func TestFinishSprintRunKeepsOtherRuns(t *testing.T) {
+	// タイマーの実行中に timersetting や別のスプリントの記録が変わっても、終了時の保存で消さない
+	setupStore(t)
+	if err := recordSprintRun(&Timer{Plannning: 10, Development: 20, Review: 30, SprintNumber: 1}); err != nil {
+		t.Fatal(err)
+	}
+	settings := &Timer{Plannning: 10, Development: 20, Review: 30, SprintNumber: 2}
+	if err := recordSprintRun(settings); err != nil {
+		t.Fatal(err)
+	}
+	timer := newSprintTimer(settings)
+	timer.start()
+
+	mustRun(t, "timersetting", "5", "40", "15")
+	if err := finishSprintRun(2, timer, true); err != nil {
+		t.Fatal(err)
+	}
+
+	saved, err := loadTimerSettings()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if saved.Plannning != 5 || saved.Development != 40 || saved.Review != 15 {
+		t.Errorf("設定 = %d/%d/%d, want 5/40/15", saved.Plannning, saved.Development, saved.Review)
+	}
+	if _, ok := saved.Runs[1]; !ok {
+		t.Error("スプリント 1 の実行記録が消えました")
+	}
+	if run := saved.Runs[2]; run.StartedAt.IsZero() || run.State != "completed" {
+		t.Errorf("スプリント 2 の実行記録 = %+v", run)
+	}
+}
+
+func TestProgressBar(t *testing.T) {
+	tests := []struct {
+		elapsed, planned time.Duration
+		width            int
+		want             string
+	}{
+		{0, 10 * time.Minute, 4, "░░░░"},
+		{5 * time.Minute, 10 * time.Minute, 4, "██░░"},
+		{9 * time.Minute, 10 * time.Minute, 4, "███░"}, // 切り捨て
+		{10 * time.Minute, 10 * time.Minute, 4, "████"},
+		{15 * time.Minute, 10 * time.Minute, 4, "████"}, // 延長せずに超過
+		{-time.Minute, 10 * time.Minute, 4, "░░░░"},
+		{time.Minute, 0, 4, "████"}, // 予定 0 分のフェーズ
+		{time.Minute, 10 * time.Minute, 0, ""},
+	}
+	for _, tt := range tests {
+		if got := progressBar(tt.elapsed, tt.planned, tt.width); got != tt.want {
+			t.Errorf("progressBar(%v, %v, %d) = %q, want %q", tt.elapsed, tt.planned, tt.width, got, tt.want)
+		}
+	}
+}
+
+func TestWriteTaskPanes(t *testing.T) {
+	tasks := []Task{
+		{ID: 1, Title: "a", Status: "todo", TaskWeight: 1, SprintNumber: 1},
+		{ID: 2, Title: "b", Status: "doing", TaskWeight: 1, SprintNumber: 1},
+		{ID: 3, Title: "c", Status: "review", TaskWeight: 1, SprintNumber: 1},
+		{ID: 4, Title: "d", Status: "blocked", TaskWeight: 1, SprintNumber: 1},
+		{ID: 5, Title: "e", Status: "done", TaskWeight: 1, SprintNumber: 1},
+	}
+	panes := []*tview.TextView{tview.NewTextView(), tview.NewTextView(), tview.NewTextView()}
+	writeTaskPanes(panes, tasks, tasksByID(tasks))
+
+	want := []struct {
+		title string
+		lines []string
+	}{
+		{" todo (1) ", []string{"#1 a"}},
+		{" doing (3) ", []string{"#2 b", "#3 c [gray](review)[-]", "#4 d [gray](blocked)[-]"}}, // 途中のステータスも Doing に含める
+		{" done (1) ", []string{"#5 e"}},
+	}
+	for i, pane := range panes {
+		if got := pane.GetTitle(); got != want[i].title {
+			t.Errorf("ペイン %d のタイトル = %q, want %q", i, got, want[i].title)
+		}
+		var titles []string
+		for j, line := range strings.Split(strings.TrimSuffix(pane.GetText(false), "\n"), "\n") {
+			if j%2 == 0 { // 2行目はカードの詳細
+				titles = append(titles, line)
+			}
+		}
+		if !reflect.DeepEqual(titles, want[i].lines) {
+			t.Errorf("ペイン %d = %q, want %q", i, titles, want[i].lines)
+		}
+	}
+}
@@ -1,20 +1,16 @@
 package main
 
 import (
-	"bufio"
 	"context"
 	"encoding/json"
 	"fmt"
 	"github.com/benoitmasson/plotters/piechart"
-	"github.com/gdamore/tcell/v2"
-	"github.com/olekukonko/tablewriter"
-	"github.com/rivo/tview"
 	"gonum.org/v1/plot"
 	"gonum.org/v1/plot/plotter"
 	"gonum.org/v1/plot/vg"
 	"hash/fnv"
 	"image/color"
-	"log"
+	"io"
 	"math"
 	"os"
 	"sort"
@@ -24,25 +20,76 @@ import (
 )
 
 type Task struct {
-	ID           int    `json:"id"`
-	Title        string `json:"title"`
-	Done         bool   `json:"done"`
-	SprintNumber int    `json:"sprint_number,omitempty"` // Optional field for sprint number
-	TaskWeight   int    `json:"task_weight,omitempty"`   // Optional field for task weight
-	Assignees    string `json:"assignees"`
+	ID           int          `json:"id"`
+	Title        string       `json:"title"`
+	Status       string       `json:"status"`                  // ワークフロー上のステータス（workflow.go）
+	SprintNumber int          `json:"sprint_number,omitempty"` // Optional field for sprint number
+	TaskWeight   int          `json:"task_weight,omitempty"`   // Optional field for task weight
+	Assignees    AssigneeList `json:"assignees"`
+	CreatedAt    *time.Time   `json:"created_at,omitempty"`   // 追加日時
+	StartedAt    *time.Time   `json:"started_at,omitempty"`   // 初めて割り当てた／作業中にした日時
+	CompletedAt  *time.Time   `json:"completed_at,omitempty"` // 完了日時
+	CarryOver    int          `json:"carry_over,omitempty"`   // 次のスプリントへ持ち越された回数
+	Rank         int          `json:"rank,omitempty"`         // バックログ内の優先順位（backlog.go）
+	ParentID     int          `json:"parent_id,omitempty"`    // 親タスクの ID（子タスクの場合）
+	EpicID       int          `json:"epic_id,omitempty"`      // 所属するエピック（epic.go）
+	DependsOn    []int        `json:"depends_on,omitempty"`   // 先に完了している必要があるタスク（depend.go）
+	Labels       []string     `json:"labels,omitempty"`       // bug / feature などの分類（label.go）
+	Description  string       `json:"description,omitempty"`  // 詳細な説明（detail.go）
+	Criteria     []Criterion  `json:"criteria,omitempty"`     // 受け入れ条件
+	Comments     []Comment    `json:"comments,omitempty"`     // コメント（返信でスレッドになる）
 }
 
 type Timer struct {
-	Plannning    int `json:"planning"`
-	Development  int `json:"development"`
-	Review       int `json:"review"`
-	SprintNumber int `json:"sprint_number"`
+	Plannning    int               `json:"planning"`
+	Development  int               `json:"development"`
+	Review       int               `json:"review"`
+	SprintNumber int               `json:"sprint_number"`
+	Runs         map[int]SprintRun `json:"runs,omitempty"` // スプリント番号 → 実行記録
+}
+
+// SprintRun は timerstart で実行したスプリントの開始日時と各フェーズの時間（分）です。
+// バーンダウンチャートのタイムボックスに使用します。
+// タイマーの終了時に、各フェーズの予定（延長を含む）と実績を Phases に記録します。
+type SprintRun struct {
+	StartedAt   time.Time  `json:"started_at"`
+	Plannning   int        `json:"planning"`
+	Development int        `json:"development"`
+	Review      int        `json:"review"`
+	Phases      []PhaseRun `json:"phases,omitempty"`
+	EndedAt     *time.Time `json:"ended_at,omitempty"`
+	State       string     `json:"state,omitempty"` // "completed"（すべてのフェーズが終了）または "exited"（途中で exit）
+}
+
+// PhaseRun はタイマーの1フェーズの予定と実績です。
+type PhaseRun struct {
+	Name    string `json:"name"`
+	Planned int    `json:"planned_minutes"` // extend で延長した時間を含む
+	Actual  int    `json:"actual_seconds"`  // 一時停止していた時間を除く
+	Skipped bool   `json:"skipped,omitempty"`
+}
+
+// phaseMinutes は各フェーズの時間（分）です。タイマーの終了時に記録した実績があればそれを使い、
+// 実行中や記録のない場合は予定の時間を使います。途中で exit して始まらなかったフェーズは延長後の予定です。
+func (r SprintRun) phaseMinutes() []float64 {
+	minutes := []float64{float64(r.Plannning), float64(r.Development), float64(r.Review)}
+	if len(r.Phases) != len(minutes) {
+		return minutes
+	}
+	for i, p := range r.Phases {
+		if p.Actual > 0 || p.Skipped {
+			minutes[i] = float64(p.Actual) / 60
+		} else {
+			minutes[i] = float64(p.Planned)
+		}
+	}
+	return minutes
 }
 
 const dataFile = "todo.json"
 const timersettingFile = "timer_setting.json"
 
-// ColorFromName は人名を安定した色(RGBA)に変換します。
+// ColorFromName は人名やラベル名を安定した色(RGBA)に変換します。
 // 同じ name を渡せば常に同じ色が返ります。
 func ColorFromName(name string) color.RGBA {
 	// 1) 64bit FNV ハッシュ
@@ -86,23 +133,6 @@ func hslToRGBA(h, s, l float64) color.RGBA {
 	}
 }
 
-func loadTasks() ([]Task, error) {
-	file, err := os.Open(dataFile)
-	if err != nil {
-		if os.IsNotExist(err) {
-			return []Task{}, nil
-		}
-		return nil, err
-	}
-	defer file.Close()
-
-	var tasks []Task
-	if err := json.NewDecoder(file).Decode(&tasks); err != nil {
-		return nil, err
-	}
-	return tasks, nil
-}
-
 func loadTimerSettings() (*Timer, error) {
 	file, err := os.Open(timersettingFile)
 	if err != nil {
@@ -120,13 +150,13 @@ func loadTimerSettings() (*Timer, error) {
 	return settings, nil
 }
 
-func saveTasks(tasks []Task) error {
-	file, err := os.Create(dataFile)
-	if err != nil {
-		return err
+// findTask はタスク id を読み込みます。存在しない場合は notFound のエラーを返します。
+func findTask(id int) (Task, error) {
+	task, err := store.Get(id)
+	if err == ErrTaskNotFound {
+		return Task{}, notFound(err, "タスク #%d は存在しません", id)
 	}
-	defer file.Close()
-	return json.NewEncoder(file).Encode(tasks)
+	return task, err
 }
 
 func nextID(tasks []Task) int {
@@ -139,200 +169,328 @@ func nextID(tasks []Task) int {
 	return maxID + 1
 }
 
-func AddTask(title string, sprintNumber int, taskWeight int) {
-	tasks, err := loadTasks()
+// NewTask は AddTask で追加するタスクの内容です。
+type NewTask struct {
+	Title        string
+	SprintNumber int // 0 はバックログ
+	TaskWeight   int // 0 は未見積もり
+	ParentID     int // 指定すると子タスクになる
+	EpicID       int // 省略した場合は親のエピックを引き継ぐ
+	Description  string
+	Assignees    []string // applyAssignArgs の書式（name / name:share）
+	Labels       []string
+}
+
+// AddTask はタスクを追加します。
+func AddTask(nt NewTask) error {
+	unlock, err := store.Lock()
 	if err != nil {
-		panic(err)
+		return err
 	}
+	defer unlock()
 
-	newTask := Task{
-		ID:           nextID(tasks),
-		Title:        title,
-		Done:         false,
-		SprintNumber: sprintNumber, // Default value for sprint number
-		TaskWeight:   taskWeight,   // Default value for task weight
+	if err := checkText("タイトル", nt.Title); err != nil {
+		return err
+	}
+	if nt.TaskWeight != 0 && !estimation.Allows(nt.TaskWeight) {
+		return fmt.Errorf("タスクウェイトは %s のいずれかで指定してください", estimation)
+	}
+	// スプリント番号 0 はバックログ
+	if nt.SprintNumber != 0 {
+		if err := checkSprintOpen(nt.SprintNumber); err != nil {
+			return err
+		}
+	}
+
+	epicID, err := resolveTaskLinks(nt.ParentID, nt.EpicID)
+	if err != nil {
+		return err
+	}
+	assignees, err := applyAssignArgs(AssigneeList{}, nt.Assignees)
+	if err != nil {
+		return err
+	}
+	labels, err := applyLabelArgs(nil, nt.Labels)
+	if err != nil {
+		return err
 	}
-	tasks = append(tasks, newTask)
 
-	if err := saveTasks(tasks); err != nil {
-		panic(err)
+	newTask := Task{
+		Title:        nt.Title,
+		Status:       workflow.Initial,
+		SprintNumber: nt.SprintNumber,
+		TaskWeight:   nt.TaskWeight,
+		Assignees:    assignees,
+		CreatedAt:    timePtr(time.Now()),
+		ParentID:     nt.ParentID,
+		EpicID:       epicID,
+		Labels:       labels,
+		Description:  nt.Description,
+	}
+	// assign と同様、担当者付きで追加したタスクは作業中にする
+	if len(assignees) > 0 && workflow.CanMove(newTask.Status, workflow.Start) {
+		newTask.setStatus(workflow.Start)
+	}
+	tasks, err := store.List()
+	if err != nil {
+		return err
+	}
+	if nt.SprintNumber == 0 {
+		newTask.Rank = nextBacklogRank(tasks)
+	} else {
+		violations, err := capacityViolations(append(tasks, newTask), nt.SprintNumber, assignees.names(), true)
+		if err != nil {
+			return err
+		}
+		if err := checkOverCapacity(violations); err != nil {
+			return err
+		}
 	}
+	created, err := store.Create(newTask)
+	if err != nil {
+		return err
+	}
+	recordEvent(created, "add", "title", "", created.Title)
+	recordTaskOp(fmt.Sprintf("add #%d", created.ID), created.ID, nil, &created)
+	return nil
 }
 
-func ListTasks() {
-	tasks, err := loadTasks()
+// ListTasks はタスクを一覧表示します。label を指定するとそのラベルが付いたタスクだけを表示します。
+func ListTasks(label string) error {
+	all, err := store.List()
 	if err != nil {
-		panic(err)
+		return err
 	}
 
-	table := tablewriter.NewWriter(os.Stdout)
-	table.SetHeader([]string{"ID", "Title", "Sprint_Number", "Task_Weight", "Assignees", "Status"})
+	table := &Table{Header: []string{"ID", "Title", "Epic", "Sprint_Number", "Task_Weight", "Assignees", "Labels", "Status"}}
 
-	for _, task := range tasks {
-		status := "[ ]"
-		if task.Done {
-			status = "[x]"
+	// 子タスクは親の直後に字下げして表示する
+	byID := tasksByID(all)
+	children := childrenOf(all)
+	for _, n := range taskTree(filterByLabel(all, label)) {
+		task := n.Task
+		epic := "-"
+		if id := epicOf(task, byID); id != 0 {
+			epic = strconv.Itoa(id)
 		}
 		row := []string{
 			strconv.Itoa(task.ID),
-			task.Title,
+			treeTitle(n),
+			epic,
 			strconv.Itoa(task.SprintNumber),
-			strconv.Itoa(task.TaskWeight),
-			task.Assignees,
-			status,
+			weightWithRollup(task, children),
+			task.Assignees.String(),
+			formatLabels(task.Labels),
+			statusWithBlockers(task, byID),
 		}
 		table.Append(row)
 	}
 
-	table.Render()
+	output.AddTable(table)
+	return nil
 }
 
-func ListDoingTasks(sprint int) {
-	tasks, err := loadTasks()
+// sprintTasks はタイマーで表示するスプリントのタスクを返します。現在のスプリントに加え、
+// まだ終了処理をしていない過去のスプリントのタスクも含めます。label を指定するとそのラベルのタスクに絞り込みます。
+// 結果はスプリント番号、ID の順に並びます。all はすべてのタスクです（依存先の表示用）。
+func sprintTasks(sprint int, label string) (tasks []Task, all []Task, err error) {
+	all, err = store.List()
 	if err != nil {
-		panic(err)
+		return nil, nil, err
 	}
 
-	// フィルタ & グループ分け
-	todo := []Task{}
-	doing := []Task{}
-	done := []Task{}
-	for _, task := range tasks {
-		if task.SprintNumber <= sprint {
-			if task.Done == true {
-				done = append(done, task)
-			} else if strings.TrimSpace(task.Assignees) == "" {
-				todo = append(todo, task)
-			} else {
-				doing = append(doing, task)
-			}
+	sprints, err := store.ListSprints()
+	if err != nil {
+		return nil, nil, err
+	}
+	closed := make(map[int]bool)
+	for _, sp := range sprints {
+		closed[sp.Number] = sp.State == SprintClosed
+	}
+
+	for _, task := range filterByLabel(all, label) {
+		if task.SprintNumber == sprint || (task.SprintNumber > 0 && task.SprintNumber < sprint && !closed[task.SprintNumber]) {
+			tasks = append(tasks, task)
 		}
 	}
+	sort.Slice(tasks, func(i, j int) bool {
+		if tasks[i].SprintNumber == tasks[j].SprintNumber {
+			return tasks[i].ID < tasks[j].ID
+		}
+		return tasks[i].SprintNumber < tasks[j].SprintNumber
+	})
+	return tasks, all, nil
+}
 
-	// ソート（どちらも同様に）
-	sortTasks := func(ts []Task) {
-		sort.Slice(ts, func(i, j int) bool {
-			if ts[i].SprintNumber == ts[j].SprintNumber {
-				return ts[i].ID < ts[j].ID
-			}
-			return ts[i].SprintNumber < ts[j].SprintNumber
-		})
+// ListDoingTasks はスプリントのタスクをステータスごとに表示します。label を指定するとそのラベルのタスクに絞り込みます。
+func ListDoingTasks(sprint int, label string) error {
+	tasks, all, err := sprintTasks(sprint, label)
+	if err != nil {
+		return err
+	}
+	groups := make(map[string][]Task)
+	for _, task := range tasks {
+		groups[task.Status] = append(groups[task.Status], task)
 	}
-	sortTasks(todo)
-	sortTasks(doing)
-	sortTasks(done)
 
-	// 表示関数
+	// 表示関数（依存先が未完了のタスクは待っているタスクを表示）
+	byID := tasksByID(all)
 	renderTable := func(title string, ts []Task) {
-		fmt.Printf("\n=== %s ===\n", title)
-		table := tablewriter.NewWriter(os.Stdout)
-		table.SetHeader([]string{"ID", "Title", "Sprint", "Weight", "Assignees", "Status"})
+		table := &Table{Title: title, Header: []string{"ID", "Title", "Sprint", "Weight", "Assignees", "Labels", "Status"}}
 		for _, t := range ts {
-			status := "[ ]"
-			if t.Done {
-				status = "[x]"
-			}
 			row := []string{
 				strconv.Itoa(t.ID),
 				t.Title,
 				strconv.Itoa(t.SprintNumber),
 				strconv.Itoa(t.TaskWeight),
-				t.Assignees,
-				status,
+				t.Assignees.String(),
+				formatLabels(t.Labels),
+				statusWithBlockers(t, byID),
 			}
 			table.Append(row)
 		}
-		table.Render()
+		output.AddTable(table)
 	}
 
-	// 出力
-	renderTable("Todo", todo)
-	renderTable("Doing", doing)
-	renderTable("Done", done)
+	// 出力（ワークフローの定義順）
+	for _, status := range workflow.Statuses {
+		renderTable(status, groups[status])
+	}
+	return nil
 }
 
-func AssignTask(id int, name string) {
-	tasks, err := loadTasks()
+// AssignTask は担当者を追加・削除します。args の書式は applyAssignArgs を参照。
+func AssignTask(id int, args []string) error {
+	unlock, err := store.Lock()
 	if err != nil {
-		panic(err)
+		return err
 	}
+	defer unlock()
 
-	isExist := false
+	task, err := findTask(id)
+	if err != nil {
+		return err
+	}
 
-	for i, t := range tasks {
-		if t.ID == id {
-			tasks[i].Assignees = name
-			isExist = true
-			break
+	assignees, err := applyAssignArgs(task.Assignees, args)
+	if err != nil {
+		return err
+	}
+	// 追加・配分変更された担当者のキャパシティを確認
+	changed := []string{}
+	for _, a := range assignees {
+		if !containsAssignee(task.Assignees, a) {
+			changed = append(changed, a.Name)
 		}
 	}
-
-	if !isExist {
-		fmt.Print("task not found")
+	if len(changed) > 0 {
+		tasks, err := store.List()
+		if err != nil {
+			return err
+		}
+		for i := range tasks {
+			if tasks[i].ID == id {
+				tasks[i].Assignees = assignees
+			}
+		}
+		violations, err := capacityViolations(tasks, task.SprintNumber, changed, false)
+		if err != nil {
+			return err
+		}
+		if err := checkOverCapacity(violations); err != nil {
+			return err
+		}
 	}
 
-	if err := saveTasks(tasks); err != nil {
-		panic(err)
+	before := task
+	oldAssignees, oldStatus := task.Assignees.String(), task.Status
+	task.Assignees = assignees
+	// 未着手のタスクに初めて担当者が付いたら作業中にする（外しても戻さない）
+	if len(assignees) > 0 && task.Status == workflow.Initial && workflow.CanMove(task.Status, workflow.Start) {
+		task.setStatus(workflow.Start)
 	}
+	if len(assignees) > 0 && task.StartedAt == nil {
+		task.StartedAt = timePtr(time.Now())
+	}
+	if err := store.Update(task); err != nil {
+		return err
+	}
+	recordEvent(task, "assign", "assignees", oldAssignees, task.Assignees.String())
+	if task.Status != oldStatus {
+		recordEvent(task, "assign", "status", oldStatus, task.Status)
+	}
+	recordTaskOp(fmt.Sprintf("assign #%d", id), id, &before, &task)
+	return nil
 }
 
-func CompleteTask(id int) {
-	tasks, err := loadTasks()
+func CompleteTask(id int) error {
+	unlock, err := store.Lock()
 	if err != nil {
-		panic(err)
+		return err
 	}
+	defer unlock()
 
-	isExist := false
-
-	for i, t := range tasks {
-		if t.ID == id {
-			tasks[i].Done = true
-			isExist = true
-			break
-		}
+	task, err := findTask(id)
+	if err != nil {
+		return err
 	}
 
-	if !isExist {
-		fmt.Print("task not found")
+	if err := workflow.checkMove(task.Status, workflow.Done); err != nil {
+		return err
 	}
-
-	if err := saveTasks(tasks); err != nil {
-		panic(err)
+	warnUncheckedCriteria(task)
+	before := task
+	oldStatus := task.Status
+	task.setStatus(workflow.Done)
+	if err := store.Update(task); err != nil {
+		return err
+	}
+	recordEvent(task, "complete", "status", oldStatus, task.Status)
+	recordTaskOp(fmt.Sprintf("complete #%d", id), id, &before, &task)
+	// 完了は保存済みのため、着手可能になったタスクの表示に失敗しても警告にとどめる
+	if err := reportUnblocked(id); err != nil {
+		output.Warnf("着手可能になったタスクを確認できませんでした: %v", err)
 	}
+	return nil
 }
 
-func DeleteTask(id int) {
-	tasks, err := loadTasks()
+func DeleteTask(id int) error {
+	unlock, err := store.Lock()
 	if err != nil {
-		panic(err)
+		return err
 	}
+	defer unlock()
 
-	newTasks := make([]Task, 0, len(tasks))
-	isExist := false
-
-	for _, t := range tasks {
-		if t.ID == id {
-			isExist = true
-			continue // このタスクを削除（スキップ）
-		}
-		newTasks = append(newTasks, t)
+	task, err := findTask(id)
+	if err != nil {
+		return err
 	}
-
-	if !isExist {
-		fmt.Println("task not found")
-		return
+	tasks, err := store.List()
+	if err != nil {
+		return err
+	}
+	if n := len(childrenOf(tasks)[id]); n > 0 {
+		return fmt.Errorf("タスク #%d には子タスクが %d 件あるため削除できません（先に子タスクを削除してください）", id, n)
 	}
 
-	if err := saveTasks(newTasks); err != nil {
-		panic(err)
+	if err := store.Delete(id); err != nil {
+		return err
 	}
+	recordEvent(task, "delete", "task", taskSnapshot(task), "")
+	recordTaskOp(fmt.Sprintf("delete #%d", id), id, &task, nil)
+	return nil
 }
 
-func TimerStartSprint() {
+// TimerStartSprint はスプリントタイマーを開始します。label は開発フェーズのタスク表示の絞り込みに使います。
+func TimerStartSprint(label string) error {
 	//jsonの読み込み
 	settings, err := loadTimerSettings()
 	if err != nil {
-		panic(err)
+		return err
+	}
+	number, err := currentSprint()
+	if err != nil {
+		return err
 	}
 	if settings == nil {
 		// デフォルトのタイマー設定を使用
@@ -340,70 +498,83 @@ func TimerStartSprint() {
 			Plannning:    15,
 			Development:  60,
 			Review:       15,
-			SprintNumber: 0,
+			SprintNumber: number,
 		}
 		fmt.Println("タイマー設定ファイルが見つからないため、デフォルト値を使用します。")
 	} else {
+		settings.SprintNumber = number
 		fmt.Printf("スプリント番号 : %d, スプリント計画: %d分, 開発: %d分, スプリントレビュー＋振り返り: %d分\n",
 			settings.SprintNumber, settings.Plannning, settings.Development, settings.Review)
 	}
+	if err := recordSprintRun(settings); err != nil {
+		return err
+	}
+	timer := newSprintTimer(settings)
+	activeTimer = timer
+	defer func() { activeTimer = nil }()
 
 	ctx, cancel := context.WithCancel(context.Background())
-	defer cancel() // 安全のため
+	defer cancel()
 
-	// ── 入力受付を並列実行
+	// ── 入力受付を並列実行（exit で cancel される）
 	go listenInput(ctx, cancel)
 
-	go func() {
-
-		fmt.Printf("スプリントプランニング（%d分）を開始します\n", settings.Plannning)
-		timerMinutes(settings.Plannning)
-		fmt.Println("スプリント計画が終了しました")
-
-		fmt.Println("開発（%d分）を開始します", settings.Development)
-		ListDoingTasks(settings.SprintNumber)
-		timerMinutes(settings.Development)
-		fmt.Println("開発が終了しました")
-
-		fmt.Println("スプリントレビュー＋振り返り（%d分）を開始します", settings.Review)
-		timerMinutes(settings.Review)
-		fmt.Println("スプリントレビュー＋振り返りが終了しました")
-
-		fmt.Println("=== スプリントタイムボックス終了 ===")
-
-		settings.SprintNumber += 1
-
-		file, err := os.Create(timersettingFile)
-		if err != nil {
-			panic(err)
+	onStart := func(phase int) {
+		p := timer.Phases()[phase]
+		fmt.Fprintln(os.Stderr)
+		fmt.Printf("%s（%d分）を開始します\n", p.Name, int(p.Planned/time.Minute))
+		if phase == 1 { // 開発
+			res := collect(printEntry, func() error {
+				return ListDoingTasks(settings.SprintNumber, label)
+			})
+			if res.Err != nil {
+				printError(os.Stderr, res.Err)
+			}
 		}
-		defer file.Close()
-
-		if err := json.NewEncoder(file).Encode(settings); err != nil {
-			panic(err)
+	}
+	onEnd := func(phase int) {
+		fmt.Fprintln(os.Stderr)
+		fmt.Printf("%sが終了しました\n", timer.Phases()[phase].Name)
+	}
+	onTick := func(st timerStatus) {
+		remaining := st.Planned - st.Elapsed
+		if remaining < 0 {
+			remaining = 0
 		}
-		cancel()
-
-	}()
-
-	<-ctx.Done()
+		state := ""
+		if st.Paused {
+			state = "（一時停止中）"
+		}
+		sec := int(remaining.Round(time.Second) / time.Second)
+		fmt.Fprintf(os.Stderr, "\r[タイマー] 残り: %2d分%02d秒%s", sec/60, sec%60, state)
+	}
+	completed := timer.Run(ctx, onStart, onEnd, onTick)
+	if err := finishSprintRun(settings.SprintNumber, timer, completed); err != nil {
+		return fmt.Errorf("実績を記録できませんでした: %w", err)
+	}
+	if !completed {
+		fmt.Println("タイマーを終了しました。各フェーズの実績を timer_setting.json に記録しました")
+		return nil
+	}
 
+	fmt.Println("=== スプリントタイムボックス終了 ===")
+	fmt.Printf("未完了のタスクは sprint close %d で持ち越し・バックログへの戻しができます\n", settings.SprintNumber)
+	return nil
 }
 
-// 分数を受け取ってタイマー表示する補助関数
-func timerMinutes(min int) {
-	totalSec := min * 60
-	for i := totalSec; i > 0; i-- {
-		fmt.Fprintf(os.Stderr, "\r[タイマー] 残り: %2d分%02d秒", i/60, i%60)
-		time.Sleep(1 * time.Second)
+func TimerSetting(planningTime, developmentTime, reviewTime int) error {
+	if planningTime <= 0 || developmentTime <= 0 || reviewTime <= 0 {
+		return fmt.Errorf("各フェーズの時間は 1 分以上で指定してください")
 	}
-	fmt.Fprintln(os.Stderr, "\n[タイマー] タイマー終了")
-}
+	unlock, err := acquireLock(timersettingFile + ".lock")
+	if err != nil {
+		return err
+	}
+	defer unlock()
 
-func TimerSetting(planningTime, developmentTime, reviewTime int) {
 	settings, err := loadTimerSettings()
 	if err != nil {
-		panic(err)
+		return err
 	}
 
 	timerSettings := Timer{
@@ -416,43 +587,41 @@ func TimerSetting(planningTime, developmentTime, reviewTime int) {
 		timerSettings.SprintNumber = 1
 	} else {
 		timerSettings.SprintNumber = settings.SprintNumber
+		timerSettings.Runs = settings.Runs
 	}
 
-	file, err := os.Create(timersettingFile)
-	if err != nil {
-		panic(err)
-	}
-	defer file.Close()
-
-	if err := json.NewEncoder(file).Encode(timerSettings); err != nil {
-		panic(err)
+	if err := saveTimerSettings(&timerSettings); err != nil {
+		return err
 	}
+	recordTimerOp("timersetting", settings, &timerSettings)
+	return nil
 }
 
-func ShowProgress() {
-	tasks, err := loadTasks()
+// ShowProgress は担当者ごと・エピックごとの進捗を表示します。label を指定するとそのラベルのタスクだけを集計します。
+func ShowProgress(label string) error {
+	all, err := store.List()
 	if err != nil {
-		panic(err)
+		return err
 	}
+	tasks := filterByLabel(all, label)
 
 	// assigneeごとに重みを集計
 	type progress struct {
-		doneWeight  int
-		totalWeight int
+		doneWeight  float64
+		totalWeight float64
 	}
 	progressMap := make(map[string]*progress)
 
+	// 未割り当てタスクは集計しない。複数担当者のタスクはウェイトを配分する
 	for _, task := range tasks {
-		name := task.Assignees
-		if name == "" {
-			continue // 未割り当てタスクは集計しない
-		}
-		if _, ok := progressMap[name]; !ok {
-			progressMap[name] = &progress{}
-		}
-		progressMap[name].totalWeight += task.TaskWeight
-		if task.Done {
-			progressMap[name].doneWeight += task.TaskWeight
+		for name, weight := range task.Assignees.WeightShares(task.TaskWeight) {
+			if _, ok := progressMap[name]; !ok {
+				progressMap[name] = &progress{}
+			}
+			progressMap[name].totalWeight += weight
+			if task.IsDone() {
+				progressMap[name].doneWeight += weight
+			}
 		}
 	}
 
@@ -464,80 +633,126 @@ func ShowProgress() {
 	sort.Strings(names)
 
 	// テーブル表示
-	fmt.Println("作業者\t完了重み/担当重み\t進捗率")
-	fmt.Println("-------------------------------------")
+	// 全角の名前でも列がずれないよう表示幅で揃える
+	nameWidth := columnWidth("作業者", names)
+	output.Printf("%s  完了重み/担当重み\t進捗率", padRight("作業者", nameWidth))
+	output.Println("-------------------------------------")
 	for _, name := range names {
 		p := progressMap[name]
 		rate := 0
 		if p.totalWeight > 0 {
-			rate = p.doneWeight * 100 / p.totalWeight
+			rate = int(p.doneWeight * 100 / p.totalWeight)
 		}
-		fmt.Printf("%s\t%d/%d\t\t%d%%\n", name, p.doneWeight, p.totalWeight, rate)
+		output.Printf("%s  %.1f/%.1f\t\t%d%%", padRight(name, nameWidth), p.doneWeight, p.totalWeight, rate)
 	}
 
-	// グラフ用データ作成
-	p := plot.New()
-	p.Title.Text = "Progress by Assignee"
-	p.Y.Label.Text = "Progress (%)"
-	p.NominalX(names...)
-
-	// 各作業者ごとに1本ずつBarChartを重ねて色分け
-	for i, name := range names {
-		prog := progressMap[name]
-		var rate float64
-		if prog.totalWeight > 0 {
-			rate = float64(prog.doneWeight) / float64(prog.totalWeight) * 100
-		}
-		vals := make(plotter.Values, len(names))
-		vals[i] = rate // 他は0
-		bar, err := plotter.NewBarChart(vals, vg.Points(30))
-		if err != nil {
-			fmt.Println("グラフ生成に失敗しました:", err)
-			return
+	// 担当者付きのタスクがなければグラフは出力しない
+	if len(names) > 0 {
+		// グラフ用データ作成
+		p := plot.New()
+		p.Title.Text = "Progress by Assignee"
+		if label != "" {
+			p.Title.Text += " [" + label + "]"
 		}
-		bar.LineStyle.Width = vg.Length(0)
-		bar.Color = ColorFromName(name) // 名前から色を取得
-		p.Add(bar)
-	}
-	p.Y.Max = 100
+		p.Y.Label.Text = "Progress (%)"
+		p.NominalX(names...)
+
+		// 各作業者ごとに1本ずつBarChartを重ねて色分け
+		for i, name := range names {
+			prog := progressMap[name]
+			var rate float64
+			if prog.totalWeight > 0 {
+				rate = prog.doneWeight / prog.totalWeight * 100
+			}
+			vals := make(plotter.Values, len(names))
+			vals[i] = rate // 他は0
+			bar, err := plotter.NewBarChart(vals, vg.Points(30))
+			if err != nil {
+				return fmt.Errorf("グラフ生成に失敗しました: %w", err)
+			}
+			bar.LineStyle.Width = vg.Length(0)
+			bar.Color = ColorFromName(name) // 名前から色を取得
+			p.Add(bar)
+		}
+		p.Y.Max = 100
 
-	// 横軸ラベルの角度を調整
-	p.X.Tick.Label.Rotation = 0.5 // 0.5ラジアン（約30度）傾ける
+		// 横軸ラベルの角度を調整
+		p.X.Tick.Label.Rotation = 0.5 // 0.5ラジアン（約30度）傾ける
 
-	// 余白を設定
-	p.X.Padding = vg.Points(40)
-	p.X.Min = -0.5
-	p.X.Max = float64(len(names)) - 0.5
+		// 余白を設定
+		p.X.Padding = vg.Points(40)
+		p.X.Min = -0.5
+		p.X.Max = float64(len(names)) - 0.5
 
-	// グラフ画像として保存
-	if err := p.Save(8*vg.Inch, 4*vg.Inch, "progress.png"); err != nil {
-		fmt.Println("グラフ画像の保存に失敗しました:", err)
-		return
+		// グラフ画像として保存
+		if err := p.Save(8*vg.Inch, 4*vg.Inch, "progress.png"); err != nil {
+			return fmt.Errorf("グラフ画像の保存に失敗しました: %w", err)
+		}
+		output.Println("進捗グラフ(progress.png)を出力しました。")
 	}
-	fmt.Println("進捗グラフ(progress.png)を出力しました。")
-}
 
-func ShowContribution() {
-	// ==== 1. タスク読み込み & 集計 ==========================================
-	tasks, err := loadTasks()
-	if err != nil {
-		log.Fatalf("loadTasks 失敗: %v", err)
+	// ラベルごとの進捗（絞り込みなしの場合のみ）
+	if label == "" && len(allLabels(tasks)) > 0 {
+		if err := showLabelProgress(tasks); err != nil {
+			return fmt.Errorf("グラフ画像の保存に失敗しました: %w", err)
+		}
+		output.Println("ラベル進捗グラフ(label_progress.png)を出力しました。")
 	}
 
-	contrib := make(map[string]float64)
-	var unfinishedWeight float64
+	// エピックごとの進捗（子タスクのウェイトを含む）
+	epics, err := store.ListEpics()
+	if err != nil {
+		return err
+	}
+	if len(epics) == 0 {
+		return nil
+	}
+	done, total := epicProgress(all, label)
+	output.Println()
+	epicNames := make([]string, len(epics))
+	for i, e := range epics {
+		epicNames[i] = fmt.Sprintf("#%d %s", e.ID, e.Title)
+	}
+	epicWidth := columnWidth("エピック", epicNames)
+	output.Printf("%s  完了重み/合計重み\t進捗率", padRight("エピック", epicWidth))
+	output.Println("-------------------------------------")
+	for i, e := range epics {
+		output.Printf("%s  %d/%d\t\t%d%%", padRight(epicNames[i], epicWidth), done[e.ID], total[e.ID], progressRate(done[e.ID], total[e.ID]))
+	}
+	if err := plotEpicProgress(epics, done, total); err != nil {
+		return fmt.Errorf("グラフ画像の保存に失敗しました: %w", err)
+	}
+	output.Println("エピック進捗グラフ(epic_progress.png)を出力しました。")
+	return nil
+}
 
+// contributionShares は完了したタスクのウェイトを担当者ごと（担当者がいなければ "Unassigned"）に集計し、
+// 未完了のタスクのウェイトの合計とともに返します。
+func contributionShares(tasks []Task) (contrib map[string]float64, unfinished float64) {
+	contrib = make(map[string]float64)
 	for _, t := range tasks {
-		if t.Done {
-			name := t.Assignees
-			if name == "" {
-				name = "Unassigned"
-			}
-			contrib[name] += float64(t.TaskWeight)
-		} else {
-			unfinishedWeight += float64(t.TaskWeight)
+		if !t.IsDone() {
+			unfinished += float64(t.TaskWeight)
+			continue
+		}
+		if len(t.Assignees) == 0 {
+			contrib["Unassigned"] += float64(t.TaskWeight)
 		}
+		for name, weight := range t.Assignees.WeightShares(t.TaskWeight) {
+			contrib[name] += weight
+		}
+	}
+	return contrib, unfinished
+}
+
+// ShowContribution は完了ウェイトの担当者ごとの割合を円グラフにします。label を指定するとそのラベルのタスクだけを集計します。
+func ShowContribution(label string) error {
+	// ==== 1. タスク読み込み & 集計 ==========================================
+	tasks, err := store.List()
+	if err != nil {
+		return err
 	}
+	contrib, unfinishedWeight := contributionShares(filterByLabel(tasks, label))
 
 	// ==== 2. 円グラフ用データ作成 ==========================================
 	labels := make([]string, 0, len(contrib)+1)
@@ -555,6 +770,9 @@ func ShowContribution() {
 	// ==== 3. グラフベース生成 ==============================================
 	p := plot.New()
 	p.Title.Text = "Task Contribution"
+	if label != "" {
+		p.Title.Text += " [" + label + "]"
+	}
 	p.HideAxes() // 円グラフなので軸は非表示
 
 	// ==== 4. スライスごとに PieChart を生成して色分け =======================
@@ -569,11 +787,12 @@ func ShowContribution() {
 		// 4‑1) 1スライスだけをもつ PieChart を生成
 		pc, err := piechart.NewPieChart(plotter.Values{v})
 		if err != nil {
-			log.Fatalf("piechart 生成失敗: %v", err)
+			return fmt.Errorf("グラフ生成に失敗しました: %w", err)
 		}
 
 		// 4‑2) 色と開始位置・合計値を設定
 		pc.Color = ColorFromName(labels[i])
+		pc.Labels.TextStyle.Font = chartFontOf(12) // 日本語の名前を表示できるフォント
 		pc.Offset.Value = offset
 		pc.Total = total
 
@@ -590,9 +809,10 @@ func ShowContribution() {
 
 	// ==== 5. 保存 ==========================================================
 	if err := p.Save(6*vg.Inch, 6*vg.Inch, "contribution.png"); err != nil {
-		log.Fatalf("画像保存失敗: %v", err)
+		return fmt.Errorf("グラフ画像の保存に失敗しました: %w", err)
 	}
-	fmt.Println("貢献度円グラフを出力しました → contribution.png")
+	output.Println("貢献度円グラフを出力しました → contribution.png")
+	return nil
 }
 
 // defaultColors は必要数だけ色を返す簡易パレット
@@ -612,57 +832,26 @@ func defaultColors(n int) []color.Color {
 	return out
 }
 
+// listenInput はスプリントコンソールの入力を受け付けます。コマンドは CLI と同じ表（commands.go）で実行します。
 func listenInput(ctx context.Context, cancel context.CancelFunc) {
-	sc := bufio.NewScanner(os.Stdin)
 	for {
 		fmt.Print("\nコマンド > ")
-		if !sc.Scan() {
+		if !stdin.Scan() {
 			cancel()
 			return
 		}
-		inputs := strings.Split(sc.Text(), " ")
-		if len(inputs) < 1 {
-			fmt.Println("Help Command: [help]")
-			return
-		}
-
-		switch inputs[0] {
-		case "add":
-			if len(inputs) < 4 {
-				fmt.Println("Usage: add <title> <sprintNumber> <taskWeight>")
-				return
-			}
-			title := inputs[1]
-			sprintNumber, err1 := strconv.Atoi(inputs[2])
-			taskWeight, err2 := strconv.Atoi(inputs[3])
-			if err1 != nil || err2 != nil {
-				fmt.Println("sprintNumberとtaskWeightは数値で指定してください")
-				return
-			}
-			AddTask(title, sprintNumber, taskWeight)
-		case "list":
-			ListTasks()
-		case "assign":
-			id, _ := strconv.Atoi(inputs[1])
-			name := ""
-			if len(inputs) >= 3 {
-				name = inputs[2]
-			}
-			AssignTask(id, name)
-		case "complete":
-			id, _ := strconv.Atoi(inputs[1])
-			CompleteTask(id)
-		case "delete":
-			id, _ := strconv.Atoi(inputs[1])
-			DeleteTask(id)
+		switch line := strings.TrimSpace(stdin.Text()); line {
 		case "exit":
 			fmt.Println("exit sprint.")
 			cancel()
 			return
 		case "help":
-			fmt.Println("<Usage>\nAddTask : add <title> <sprintNumber> <taskWeight>\nListTasks :  list\nAssignTask : assign <TaskID> <UserName>\nCompleteTask : complete <TaskID>\nDeleteTask : delete <TaskID>\nExitSprint : exit ")
+			executeLine(line, frontendConsole, printEntry)
+			fmt.Println("  exit でスプリントを終了します。")
 		default:
-			fmt.Println("不明なコマンド")
+			if res := executeLine(line, frontendConsole, printEntry); res.Err != nil {
+				printError(os.Stdout, res.Err)
+			}
 		}
 
 		// タイマーが先に終わっていないかチェック
@@ -674,133 +863,45 @@ func listenInput(ctx context.Context, cancel context.CancelFunc) {
 	}
 }
 
-func saveTimerSettings(t *Timer) error {
-	file, err := os.Create(timersettingFile)
+// recordSprintRun はスプリントの開始日時とフェーズ時間を timer_setting.json に記録します。
+// 読み込み後に timersetting で変更された設定を上書きしないよう、ロックを取得してから読み込み直して保存します。
+func recordSprintRun(settings *Timer) error {
+	unlock, err := acquireLock(timersettingFile + ".lock")
 	if err != nil {
 		return err
 	}
-	defer file.Close()
-	return json.NewEncoder(file).Encode(t)
-}
-func TimerStartSprintTUI() {
-	settings, err := loadTimerSettings()
+	defer unlock()
+	current, err := loadTimerSettings()
 	if err != nil {
-		panic(err)
+		return err
 	}
-	if settings == nil {
-		settings = &Timer{
-			Plannning:    15,
-			Development:  60,
-			Review:       15,
-			SprintNumber: 0,
+	if current == nil {
+		current = &Timer{
+			Plannning:    settings.Plannning,
+			Development:  settings.Development,
+			Review:       settings.Review,
+			SprintNumber: settings.SprintNumber,
 		}
 	}
-
-	app := tview.NewApplication()
-	timerText := tview.NewTextView().SetTextAlign(tview.AlignCenter).SetTextColor(tcell.ColorGreen)
-	output := tview.NewTextView().SetDynamicColors(true).SetChangedFunc(func() {
-		app.Draw()
-	})
-	input := tview.NewInputField().SetLabel("Command > ").SetFieldWidth(40)
-
-	layout := tview.NewFlex().SetDirection(tview.FlexRow).
-		AddItem(timerText, 1, 0, false).
-		AddItem(output, 0, 1, false).
-		AddItem(input, 1, 0, true)
-
-	// コマンド処理
-	input.SetDoneFunc(func(key tcell.Key) {
-		cmd := input.GetText()
-		input.SetText("")
-		handleTUIViewCommand(cmd, output, app, settings, timerText)
-		if strings.TrimSpace(cmd) == "exit" {
-			app.Stop()
-		}
-	})
-
-	// タイマー＆フェーズ進行
-	go func() {
-		runPhase := func(name string, minutes int) {
-			for i := minutes * 60; i >= 0; i-- {
-				app.QueueUpdateDraw(func() {
-					timerText.SetText(fmt.Sprintf("[タイマー] %s 残り: %02d:%02d", name, i/60, i%60))
-				})
-				time.Sleep(time.Second)
-			}
-			app.QueueUpdateDraw(func() {
-				fmt.Fprintf(output, "[green]%s 終了\n", name)
-			})
-		}
-
-		runPhase("スプリント計画", settings.Plannning)
-
-		app.QueueUpdateDraw(func() {
-			fmt.Fprintf(output, "\n[::b]Doing タスク:\n")
-			tasks, _ := loadTasks()
-			for _, t := range tasks {
-				if t.SprintNumber == settings.SprintNumber && t.Assignees != "" && !t.Done {
-					fmt.Fprintf(output, "- #%d %s [%s] (%dpt)\n", t.ID, t.Title, t.Assignees, t.TaskWeight)
-				}
-			}
-		})
-
-		runPhase("開発", settings.Development)
-		runPhase("レビュー", settings.Review)
-
-		settings.SprintNumber++
-		_ = saveTimerSettings(settings)
-		app.QueueUpdateDraw(func() {
-			fmt.Fprintln(output, "[blue]=== スプリント終了 ===")
-		})
-	}()
-
-	if err := app.SetRoot(layout, true).EnableMouse(true).Run(); err != nil {
-		panic(err)
+	if current.Runs == nil {
+		current.Runs = make(map[int]SprintRun)
 	}
-}
-
-func handleTUIViewCommand(cmd string, out *tview.TextView, app *tview.Application, timer *Timer, timerText *tview.TextView) {
-	parts := strings.Fields(cmd)
-	if len(parts) == 0 {
-		return
+	// 同じスプリントでタイマーを再開した場合も、バーンダウンの起点がずれないよう最初の開始日時を残す
+	startedAt := time.Now()
+	if prev, ok := current.Runs[settings.SprintNumber]; ok && !prev.StartedAt.IsZero() {
+		startedAt = prev.StartedAt
 	}
-	switch parts[0] {
-	case "add":
-		if len(parts) < 4 {
-			fmt.Fprintln(out, "[red]add <title> <sprintNumber> <taskWeight>")
-			return
-		}
-		sn, _ := strconv.Atoi(parts[2])
-		tw, _ := strconv.Atoi(parts[3])
-		AddTask(parts[1], sn, tw)
-		fmt.Fprintln(out, "[green]タスク追加")
-	case "complete":
-		if len(parts) < 2 {
-			fmt.Fprintln(out, "[red]complete <TaskID>")
-			return
-		}
-		id, _ := strconv.Atoi(parts[1])
-		CompleteTask(id)
-		fmt.Fprintln(out, "[green]タスク完了")
-	case "delete":
-		id, _ := strconv.Atoi(parts[1])
-		DeleteTask(id)
-		fmt.Fprintln(out, "[green]タスク削除")
-	case "assign":
-		if len(parts) < 3 {
-			fmt.Fprintln(out, "[red]assign <TaskID> <UserName>")
-			return
-		}
-		id, _ := strconv.Atoi(parts[1])
-		name := ""
-		if len(parts) >= 3 {
-			name = parts[2]
-		}
-		AssignTask(id, name)
-		fmt.Fprintln(out, "[green]アサイン完了")
-	case "exit":
-		fmt.Fprintln(out, "[gray]終了コマンドを受け付けました")
-	default:
-		fmt.Fprintln(out, "[yellow]未知のコマンド")
+	current.Runs[settings.SprintNumber] = SprintRun{
+		StartedAt:   startedAt,
+		Plannning:   settings.Plannning,
+		Development: settings.Development,
+		Review:      settings.Review,
 	}
+	return saveTimerSettings(current)
+}
+
+func saveTimerSettings(t *Timer) error {
+	return writeFileAtomic(timersettingFile, func(w io.Writer) error {
+		return json.NewEncoder(w).Encode(t)
+	})
 }
@@ -0,0 +1,309 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
+
+// journalFile は undo / redo の履歴ファイルのパスです（todo.json → todo.json.journal.json）。
+func journalFile() string {
+	return sidecarPath(store.Path(), sidecarJournal)
+}
+
+// maxJournalOps は undo できる操作の最大数です。
+const maxJournalOps = 50
+
+// journalOp は undo / redo できる1回の操作です。変更前後の状態をそのまま保持します。
+// Before / After が nil の場合は「タスク（またはタイマー設定）が存在しない」ことを表します。
+// 複数のタスクをまとめて変更する操作（doctor の修復、バックログの並べ替え）は Bulk とし、全タスクの前後の状態を保持します。
+type journalOp struct {
+	Time        time.Time `json:"time"`
+	Desc        string    `json:"desc"`
+	TaskID      int       `json:"task_id,omitempty"`
+	Before      *Task     `json:"before,omitempty"`
+	After       *Task     `json:"after,omitempty"`
+	Timer       bool      `json:"timer,omitempty"` // タイマー設定の変更
+	TimerBefore *Timer    `json:"timer_before,omitempty"`
+	TimerAfter  *Timer    `json:"timer_after,omitempty"`
+	Bulk        bool      `json:"bulk,omitempty"` // 複数のタスクの変更
+	TasksBefore []Task    `json:"tasks_before,omitempty"`
+	TasksAfter  []Task    `json:"tasks_after,omitempty"`
+}
+
+type journal struct {
+	Undo []journalOp `json:"undo"`
+	Redo []journalOp `json:"redo"`
+}
+
+func loadJournal() (*journal, error) {
+	file, err := os.Open(journalFile())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return &journal{}, nil
+		}
+		return nil, err
+	}
+	defer file.Close()
+
+	var j journal
+	if err := json.NewDecoder(file).Decode(&j); err != nil {
+		return nil, err
+	}
+	return &j, nil
+}
+
+func saveJournal(j *journal) error {
+	return writeFileAtomic(journalFile(), func(w io.Writer) error {
+		return json.NewEncoder(w).Encode(j)
+	})
+}
+
+// recordTaskOp はタスクの変更を undo 用に記録します。
+func recordTaskOp(desc string, id int, before, after *Task) {
+	recordOp(journalOp{Time: time.Now(), Desc: desc, TaskID: id, Before: before, After: after})
+}
+
+// recordBulkOp は複数のタスクをまとめて変更した操作を、変更前後の全タスクとして undo 用に記録します。
+func recordBulkOp(desc string, before, after []Task) {
+	recordOp(journalOp{Time: time.Now(), Desc: desc, Bulk: true, TasksBefore: before, TasksAfter: after})
+}
+
+// recordTimerOp はタイマー設定の変更を undo 用に記録します。
+func recordTimerOp(desc string, before, after *Timer) {
+	recordOp(journalOp{Time: time.Now(), Desc: desc, Timer: true, TimerBefore: before, TimerAfter: after})
+}
+
+// recordOp は操作を journal に追加し、redo の履歴を破棄します。
+// 変更自体は保存済みのため、失敗しても警告のみ表示します。
+func recordOp(op journalOp) {
+	unlock, err := acquireLock(journalFile() + ".lock")
+	if err != nil {
+		output.Warnf("undo 履歴の記録に失敗しました: %v", err)
+		return
+	}
+	defer unlock()
+
+	j, err := loadJournal()
+	if err != nil {
+		output.Warnf("undo 履歴の記録に失敗しました: %v", err)
+		return
+	}
+	j.Undo = append(j.Undo, op)
+	if len(j.Undo) > maxJournalOps {
+		j.Undo = j.Undo[len(j.Undo)-maxJournalOps:]
+	}
+	j.Redo = nil
+	if err := saveJournal(j); err != nil {
+		output.Warnf("undo 履歴の記録に失敗しました: %v", err)
+	}
+}
+
+// Undo は直前の操作を取り消します。
+func Undo() error {
+	return replayJournal(true)
+}
+
+// Redo は取り消した操作をやり直します。
+func Redo() error {
+	return replayJournal(false)
+}
+
+func replayJournal(undo bool) error {
+	// ロックの取得順は store → タイマー → journal で統一
+	unlockStore, err := store.Lock()
+	if err != nil {
+		return err
+	}
+	defer unlockStore()
+	unlockTimer, err := acquireLock(timersettingFile + ".lock")
+	if err != nil {
+		return err
+	}
+	defer unlockTimer()
+	unlockJournal, err := acquireLock(journalFile() + ".lock")
+	if err != nil {
+		return err
+	}
+	defer unlockJournal()
+
+	j, err := loadJournal()
+	if err != nil {
+		return err
+	}
+
+	from, to := &j.Undo, &j.Redo
+	verb := "元に戻しました"
+	if !undo {
+		from, to = &j.Redo, &j.Undo
+		verb = "やり直しました"
+	}
+	if len(*from) == 0 {
+		if undo {
+			output.Println("元に戻せる操作はありません")
+		} else {
+			output.Println("やり直せる操作はありません")
+		}
+		return nil
+	}
+	op := (*from)[len(*from)-1]
+
+	switch {
+	case op.Timer:
+		current, target := op.TimerAfter, op.TimerBefore
+		if !undo {
+			current, target = op.TimerBefore, op.TimerAfter
+		}
+		err = restoreTimer(current, target)
+	case op.Bulk:
+		current, target := op.TasksAfter, op.TasksBefore
+		if !undo {
+			current, target = op.TasksBefore, op.TasksAfter
+		}
+		err = restoreTasks(current, target, undo)
+	default:
+		current, target := op.After, op.Before
+		if !undo {
+			current, target = op.Before, op.After
+		}
+		err = restoreTask(op.TaskID, current, target, undo)
+	}
+	if err != nil {
+		return err
+	}
+
+	*from = (*from)[:len(*from)-1]
+	*to = append(*to, op)
+	if err := saveJournal(j); err != nil {
+		return err
+	}
+	output.Printf("%s: %s", verb, op.Desc)
+	return nil
+}
+
+// restoreTask はタスクの状態が expected のままであることを確認してから target に戻します。
+func restoreTask(id int, expected, target *Task, undo bool) error {
+	var current *Task
+	task, err := store.Get(id)
+	if err == nil {
+		current = &task
+	} else if err != ErrTaskNotFound {
+		return err
+	}
+	if !sameJSON(current, expected) {
+		return fmt.Errorf("タスク #%d はその後変更されているため戻せません", id)
+	}
+
+	action := "redo"
+	if undo {
+		action = "undo"
+	}
+	switch {
+	case target == nil:
+		if err := store.Delete(id); err != nil {
+			return err
+		}
+		recordEvent(*current, action, "task", taskSnapshot(*current), "")
+	case current == nil:
+		if err := insertTask(*target); err != nil {
+			return err
+		}
+		recordEvent(*target, action, "task", "", taskSnapshot(*target))
+	default:
+		if err := store.Update(*target); err != nil {
+			return err
+		}
+		recordEvent(*target, action, "task", taskSnapshot(*current), taskSnapshot(*target))
+	}
+	return nil
+}
+
+// restoreTasks は全タスクが expected のままであることを確認してから target に置き換えます。
+// 重複した ID を含む場合もあるため、変更履歴は同じ位置のレコードどうしを比べて記録します。
+func restoreTasks(expected, target []Task, undo bool) error {
+	current, err := store.List()
+	if err != nil {
+		return err
+	}
+	if (len(current) != 0 || len(expected) != 0) && !sameJSON(current, expected) {
+		return fmt.Errorf("タスクはその後変更されているため戻せません")
+	}
+	if err := store.ReplaceAll(target); err != nil {
+		return err
+	}
+
+	action := "redo"
+	if undo {
+		action = "undo"
+	}
+	for i := 0; i < len(current) || i < len(target); i++ {
+		switch {
+		case i >= len(target):
+			recordEvent(current[i], action, "task", taskSnapshot(current[i]), "")
+		case i >= len(current):
+			recordEvent(target[i], action, "task", "", taskSnapshot(target[i]))
+		case !sameJSON(current[i], target[i]):
+			recordEvent(target[i], action, "task", taskSnapshot(current[i]), taskSnapshot(target[i]))
+		}
+	}
+	return nil
+}
+
+// insertTask は ID を保ったままタスクを追加します（削除の取り消し用）。
+func insertTask(task Task) error {
+	tasks, err := store.List()
+	if err != nil {
+		return err
+	}
+	// 並び順はそのままに、ID の大きいタスクの手前へ差し込む
+	pos := len(tasks)
+	for i, t := range tasks {
+		if t.ID > task.ID {
+			pos = i
+			break
+		}
+	}
+	tasks = append(tasks[:pos], append([]Task{task}, tasks[pos:]...)...)
+	return store.ReplaceAll(tasks)
+}
+
+// restoreTimer は各フェーズの時間が expected のままであることを確認してから target に戻します。
+func restoreTimer(expected, target *Timer) error {
+	current, err := loadTimerSettings()
+	if err != nil {
+		return err
+	}
+	if !samePhaseLengths(current, expected) {
+		return fmt.Errorf("タイマー設定はその後変更されているため戻せません")
+	}
+	if target == nil {
+		if current != nil && len(current.Runs) > 0 {
+			return fmt.Errorf("タイマーの実行記録があるため、設定ファイルを削除して戻すことはできません")
+		}
+		return os.Remove(timersettingFile)
+	}
+	// timersetting が変えるのは各フェーズの時間だけなので、その後の timerstart の実行記録や
+	// sprint start で進んだスプリント番号は現在の値を残す
+	restored := *target
+	if current != nil {
+		restored.SprintNumber, restored.Runs = current.SprintNumber, current.Runs
+	}
+	return saveTimerSettings(&restored)
+}
+
+// samePhaseLengths は a と b の各フェーズの時間が同じかどうかを返します。
+func samePhaseLengths(a, b *Timer) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Plannning == b.Plannning && a.Development == b.Development && a.Review == b.Review
+}
+
+func sameJSON(a, b interface{}) bool {
+	x, err1 := json.Marshal(a)
+	y, err2 := json.Marshal(b)
+	return err1 == nil && err2 == nil && bytes.Equal(x, y)
+}
@@ -0,0 +1,170 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+)
+
+func TestUndoRedoAdd(t *testing.T) {
+	setupStore(t)
+	mustRun(t, "add", "a")
+
+	mustRun(t, "undo")
+	if got := len(loadTasks(t)); got != 0 {
+		t.Fatalf("undo 後のタスク数 = %d, want 0", got)
+	}
+	mustRun(t, "redo")
+	if got := getTask(t, 1).Title; got != "a" {
+		t.Fatalf("redo 後の #1 = %q, want a", got)
+	}
+}
+
+func TestUndoDeleteKeepsIDAndOrder(t *testing.T) {
+	setupStore(t)
+	for _, title := range []string{"a", "b", "c"} {
+		mustRun(t, "add", title)
+	}
+	mustRun(t, "delete", "2")
+	mustRun(t, "undo")
+
+	tasks := loadTasks(t)
+	if len(tasks) != 3 {
+		t.Fatalf("タスク数 = %d, want 3", len(tasks))
+	}
+	for i, want := range []string{"a", "b", "c"} {
+		if tasks[i].ID != i+1 || tasks[i].Title != want {
+			t.Errorf("tasks[%d] = #%d %s, want #%d %s", i, tasks[i].ID, tasks[i].Title, i+1, want)
+		}
+	}
+}
+
+func TestUndoRefusesChangedTask(t *testing.T) {
+	setupStore(t)
+	mustRun(t, "add", "a")
+	mustRun(t, "move", "1", "doing")
+
+	// 記録されていない変更（他のツールでの編集など）があれば戻さない
+	task := getTask(t, 1)
+	task.Title = "edited"
+	if err := store.Update(task); err != nil {
+		t.Fatal(err)
+	}
+	if res := run(t, "undo"); res.Err == nil {
+		t.Fatal("変更されたタスクの undo がエラーになりません")
+	}
+	if got := getTask(t, 1); got.Title != "edited" || got.Status != "doing" {
+		t.Errorf("#1 = %q %s, want edited doing", got.Title, got.Status)
+	}
+}
+
+func TestUndoNothing(t *testing.T) {
+	setupStore(t)
+	res := mustRun(t, "undo")
+	if got := res.String(); got != "元に戻せる操作はありません\n" {
+		t.Errorf("output = %q", got)
+	}
+}
+
+func TestUndoTimerSetting(t *testing.T) {
+	setupStore(t)
+	mustRun(t, "timersetting", "10", "20", "30")
+	mustRun(t, "timersetting", "1", "2", "3")
+	mustRun(t, "undo")
+
+	settings, err := loadTimerSettings()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if settings.Plannning != 10 || settings.Development != 20 || settings.Review != 30 {
+		t.Errorf("undo 後の設定 = %d/%d/%d, want 10/20/30", settings.Plannning, settings.Development, settings.Review)
+	}
+}
+
+func TestUndoTimerSettingAfterTimerStart(t *testing.T) {
+	setupStore(t)
+	mustRun(t, "timersetting", "10", "20", "30")
+	mustRun(t, "timersetting", "1", "2", "3")
+	// timerstart が実行記録を追加しても、timersetting の undo はできる
+	settings, err := loadTimerSettings()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := recordSprintRun(settings); err != nil {
+		t.Fatal(err)
+	}
+	mustRun(t, "undo")
+
+	settings, err = loadTimerSettings()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if settings.Plannning != 10 || settings.Development != 20 || settings.Review != 30 {
+		t.Errorf("undo 後の設定 = %d/%d/%d, want 10/20/30", settings.Plannning, settings.Development, settings.Review)
+	}
+	if _, ok := settings.Runs[settings.SprintNumber]; !ok {
+		t.Errorf("undo で実行記録が消えました: %+v", settings.Runs)
+	}
+
+	// 設定ファイルを作った最初の timersetting は、実行記録が残るため削除して戻せない
+	if res := run(t, "undo"); res.Err == nil {
+		t.Error("実行記録があるのに設定ファイルを削除して戻せました")
+	}
+}
+
+func TestUndoTimerSettingRejectsLaterChange(t *testing.T) {
+	setupStore(t)
+	mustRun(t, "timersetting", "10", "20", "30")
+	mustRun(t, "timersetting", "1", "2", "3")
+	settings, err := loadTimerSettings()
+	if err != nil {
+		t.Fatal(err)
+	}
+	settings.Review = 99
+	if err := saveTimerSettings(settings); err != nil {
+		t.Fatal(err)
+	}
+	if res := run(t, "undo"); res.Err == nil {
+		t.Error("フェーズの時間が変わった後に undo できました")
+	}
+}
+
+func TestJournalIsBounded(t *testing.T) {
+	setupStore(t)
+	for i := 0; i < maxJournalOps+5; i++ {
+		mustRun(t, "add", "task"+strconv.Itoa(i))
+	}
+	j, err := loadJournal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(j.Undo) != maxJournalOps {
+		t.Fatalf("journal の件数 = %d, want %d", len(j.Undo), maxJournalOps)
+	}
+	if got := j.Undo[0].TaskID; got != 6 {
+		t.Errorf("最も古い操作のタスク = #%d, want #6", got)
+	}
+}
+
+func TestJournalFollowsDataFile(t *testing.T) {
+	setupStore(t)
+	mustRun(t, "add", "a")
+
+	// 別のデータファイルには別の journal が使われ、undo が混ざらない
+	dir := filepath.Dir(store.Path())
+	store = &jsonTaskStore{path: filepath.Join(dir, "team.json")}
+	mustRun(t, "add", "b")
+	if _, err := os.Stat(filepath.Join(dir, "team.json.journal.json")); err != nil {
+		t.Fatalf("team.json.journal.json がありません: %v", err)
+	}
+	mustRun(t, "undo")
+	if got := len(loadTasks(t)); got != 0 {
+		t.Errorf("team.json のタスク数 = %d, want 0", got)
+	}
+
+	store = &jsonTaskStore{path: filepath.Join(dir, dataFile)}
+	if got := len(loadTasks(t)); got != 1 {
+		t.Errorf("todo.json のタスク数 = %d, want 1", got)
+	}
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	_ "embed"
+	"fmt"
+	"os"
+	"unicode/utf8"
+
+	"github.com/mattn/go-runewidth"
+	"golang.org/x/image/font/opentype"
+	"gonum.org/v1/plot"
+	"gonum.org/v1/plot/font"
+	"gonum.org/v1/plot/plotter"
+)
+
+// 日本語（全角文字）を含むタイトルや担当者名を扱うための補助関数です。
+// 端末の表は文字数ではなく表示幅で桁を揃えます。
+// グラフの標準フォント（Liberation）には日本語の字形がないため、
+// 日本語を含むフォント（M+ 1p, fonts/LICENSE.md）を埋め込んでグラフの既定フォントにします。
+// todo_config.json の "chart_font" で別の TrueType / OpenType フォントを指定できます。
+
+//go:embed fonts/mplus-1p-regular.ttf
+var chartFontData []byte
+
+const chartTypeface = font.Typeface("AgileApp")
+
+// setupChartFont はグラフの既定フォントを登録します。path が空の場合は埋め込みフォントを使います。
+func setupChartFont(path string) error {
+	data := chartFontData
+	if path != "" {
+		b, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		data = b
+	}
+	face, err := opentype.Parse(data)
+	if err != nil {
+		return err
+	}
+	chartFont := font.Font{Typeface: chartTypeface}
+	font.DefaultCache.Add(font.Collection{{Font: chartFont, Face: face}})
+	plot.DefaultFont = chartFont
+	plotter.DefaultFont = chartFont
+	return nil
+}
+
+// chartFontOf は size の既定フォントを返します（既定フォントを使わないプロッタ向け）。
+func chartFontOf(size float64) font.Font {
+	return font.From(plot.DefaultFont, font.Length(size))
+}
+
+// textWidth は端末上の表示幅（全角文字は 2）を返します。
+func textWidth(s string) int {
+	return runewidth.StringWidth(s)
+}
+
+// columnWidth は header と values を並べるのに必要な表示幅を返します。
+func columnWidth(header string, values []string) int {
+	width := textWidth(header)
+	for _, v := range values {
+		if w := textWidth(v); w > width {
+			width = w
+		}
+	}
+	return width
+}
+
+// checkText はタイトルや名前が正しい UTF-8 で、制御文字を含まないことを確認します。
+func checkText(kind string, s string) error {
+	if !utf8.ValidString(s) {
+		return fmt.Errorf("%sに UTF-8 として不正な文字が含まれています: %q", kind, s)
+	}
+	for _, r := range s {
+		if r < 0x20 || r == 0x7f {
+			return fmt.Errorf("%sに制御文字は使えません: %q", kind, s)
+		}
+	}
+	return nil
+}
+
+// padRight は表示幅（全角文字は 2）が width になるよう s の右を空白で埋めます。
+func padRight(s string, width int) string {
+	return runewidth.FillRight(s, width)
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+	"unicode"
+)
+
+func TestTextWidth(t *testing.T) {
+	tests := []struct {
+		in   string
+		want int
+	}{
+		{"", 0},
+		{"taro", 4},
+		{"太郎", 4},
+		{"ﾀﾛｳ", 3}, // 半角カナは 1
+		{"資料sakusei", 11},
+		{"ログイン画面 v2", 15},
+	}
+	for _, tt := range tests {
+		if got := textWidth(tt.in); got != tt.want {
+			t.Errorf("textWidth(%q) = %d, want %d", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestPadRight(t *testing.T) {
+	tests := []struct {
+		in    string
+		width int
+		want  string
+	}{
+		{"taro", 6, "taro  "},
+		{"太郎", 6, "太郎  "},
+		{"太郎a", 6, "太郎a "},
+		{"太郎", 4, "太郎"},
+		{"山田太郎", 4, "山田太郎"}, // 幅を超える場合は切り詰めない
+	}
+	for _, tt := range tests {
+		if got := padRight(tt.in, tt.width); got != tt.want {
+			t.Errorf("padRight(%q, %d) = %q, want %q", tt.in, tt.width, got, tt.want)
+		}
+	}
+}
+
+func TestColumnWidth(t *testing.T) {
+	tests := []struct {
+		header string
+		values []string
+		want   int
+	}{
+		{"作業者", nil, 6},
+		{"作業者", []string{"taro", "jiro"}, 6},
+		{"作業者", []string{"taro", "山田太郎"}, 8},
+		{"Name", []string{"hanako", "花子"}, 6},
+		{"Name", []string{"花子さん"}, 8},
+	}
+	for _, tt := range tests {
+		if got := columnWidth(tt.header, tt.values); got != tt.want {
+			t.Errorf("columnWidth(%q, %q) = %d, want %d", tt.header, tt.values, got, tt.want)
+		}
+	}
+}
+
+// tableLines は表の罫線・行を取り出します。
+func tableLines(s string) []string {
+	lines := []string{}
+	for _, line := range strings.Split(s, "\n") {
+		if strings.HasPrefix(line, "+") || strings.HasPrefix(line, "|") {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+func TestListAlignsMixedWidthRows(t *testing.T) {
+	setupStore(t,
+		Task{ID: 1, Title: "shiryou", Status: "todo", SprintNumber: 1, TaskWeight: 3, Assignees: AssigneeList{{Name: "taro"}}},
+		Task{ID: 2, Title: "資料作成", Status: "doing", SprintNumber: 1, TaskWeight: 5, Assignees: AssigneeList{{Name: "山田"}}},
+		Task{ID: 3, Title: "API設計 v2", Status: "done", SprintNumber: 1, TaskWeight: 8, Assignees: AssigneeList{{Name: "hanako"}, {Name: "花子"}}},
+	)
+	lines := tableLines(mustRun(t, "list").String())
+	if len(lines) != 7 { // 罫線3 + 見出し1 + 行3
+		t.Fatalf("表の行数 = %d, want 7:\n%s", len(lines), strings.Join(lines, "\n"))
+	}
+
+	// すべての行の表示幅と、列の区切りの位置が揃っていること
+	border := lines[0]
+	for _, line := range lines {
+		if textWidth(line) != textWidth(border) {
+			t.Errorf("表示幅が揃っていません:\n%s\n%s", border, line)
+		}
+		if line[0] == '|' && separatorColumns(line) != separatorColumns(border) {
+			t.Errorf("列の位置が揃っていません:\n%s\n%s", border, line)
+		}
+	}
+}
+
+// separatorColumns は | または + が現れる表示上の桁を返します。
+func separatorColumns(line string) string {
+	cols := []string{}
+	col := 0
+	for _, r := range line {
+		if r == '|' || r == '+' {
+			cols = append(cols, strconv.Itoa(col))
+		}
+		col += textWidth(string(r))
+	}
+	return strings.Join(cols, ",")
+}
+
+func TestProgressAlignsMixedWidthNames(t *testing.T) {
+	setupStore(t,
+		Task{ID: 1, Title: "a", Status: "done", SprintNumber: 1, TaskWeight: 3, Assignees: AssigneeList{{Name: "taro"}}},
+		Task{ID: 2, Title: "b", Status: "todo", SprintNumber: 1, TaskWeight: 5, Assignees: AssigneeList{{Name: "山田太郎"}}},
+		Task{ID: 3, Title: "c", Status: "done", SprintNumber: 1, TaskWeight: 2, Assignees: AssigneeList{{Name: "hanakoさん"}}},
+	)
+	if err := setupChartFont(""); err != nil {
+		t.Fatal(err)
+	}
+	out := mustRun(t, "progress").String()
+
+	// 作業者の列の後ろ（完了重み）が、見出しも含めて同じ表示上の桁から始まること
+	columns := map[int][]string{}
+	for _, line := range strings.Split(out, "\n") {
+		i := strings.IndexFunc(line, func(r rune) bool { return unicode.IsDigit(r) || r == '完' })
+		if i < 0 || strings.HasPrefix(line, "-") {
+			continue
+		}
+		col := textWidth(line[:i])
+		columns[col] = append(columns[col], line)
+	}
+	if len(columns) != 1 {
+		t.Errorf("完了重みの列が揃っていません:\n%s", out)
+	}
+	for _, lines := range columns {
+		if len(lines) != 4 { // 見出し + 3人
+			t.Errorf("行数 = %d, want 4:\n%s", len(lines), out)
+		}
+	}
+}
@@ -0,0 +1,201 @@
+package main
+
+import (
+	"fmt"
+	"image/color"
+	"math"
+	"sort"
+	"strconv"
+
+	"gonum.org/v1/plot"
+	"gonum.org/v1/plot/plotter"
+	"gonum.org/v1/plot/vg"
+)
+
+// sprintVelocity はスプリントごとの計画ウェイトと完了ウェイトです。
+type sprintVelocity struct {
+	Sprint    int
+	State     string // スプリントの記録がなければ空
+	Committed int
+	Completed int
+}
+
+// Closed は終了したスプリントかどうかです。平均などの集計は終了したスプリントのみを対象にします
+// （実行中・計画中のスプリントは完了ウェイトが途中の値のため）。
+func (v sprintVelocity) Closed() bool {
+	return v.State == SprintClosed
+}
+
+// collectVelocity はタスクをスプリントごとに集計し、スプリント番号順に返します。
+// sprints はスプリントの記録で、各スプリントの状態に使います。
+func collectVelocity(tasks []Task, sprints []Sprint) []sprintVelocity {
+	bySprint := make(map[int]*sprintVelocity)
+	get := func(number int) *sprintVelocity {
+		v, ok := bySprint[number]
+		if !ok {
+			v = &sprintVelocity{Sprint: number}
+			bySprint[number] = v
+		}
+		return v
+	}
+	for _, t := range tasks {
+		if t.SprintNumber <= 0 {
+			continue
+		}
+		v := get(t.SprintNumber)
+		v.Committed += t.TaskWeight
+		if t.IsDone() {
+			v.Completed += t.TaskWeight
+		}
+	}
+	for _, sp := range sprints {
+		// 終了したスプリントはタスクがすべて持ち越された場合も集計に含める
+		if _, ok := bySprint[sp.Number]; ok || sp.State == SprintClosed {
+			v := get(sp.Number)
+			v.State = sp.State
+			// 持ち越し・バックログへ戻した・削除したタスクはこのスプリントに残っていないため、終了時に記録したウェイトを計画に戻す
+			v.Committed += sp.CarryOver.removedWeight()
+		}
+	}
+
+	result := make([]sprintVelocity, 0, len(bySprint))
+	for _, v := range bySprint {
+		result = append(result, *v)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Sprint < result[j].Sprint })
+	return result
+}
+
+// ShowVelocity はスプリントごとの計画／完了ウェイト、移動平均、標準偏差を表示し、
+// velocity.png に棒グラフを出力します。window は移動平均のスプリント数です。
+// 移動平均・平均・標準偏差は終了したスプリントのみで求めます。
+func ShowVelocity(window int) error {
+	tasks, err := store.List()
+	if err != nil {
+		return err
+	}
+	sprints, err := store.ListSprints()
+	if err != nil {
+		return err
+	}
+	velocities := collectVelocity(tasks, sprints)
+	if len(velocities) == 0 {
+		output.Println("集計できるスプリントがありません。")
+		return nil
+	}
+
+	// ==== 1. テーブル表示 ==================================================
+	table := &Table{Header: []string{"Sprint", "State", "Committed", "Completed", "Rate", fmt.Sprintf("Rolling Avg (%d)", window)}}
+	averages := rollingAverages(velocities, window)
+	completed := make([]float64, 0, len(velocities))
+	for _, v := range velocities {
+		rate := 0
+		if v.Committed > 0 {
+			rate = v.Completed * 100 / v.Committed
+		}
+		avg := "-"
+		if v.Closed() {
+			completed = append(completed, float64(v.Completed))
+			avg = fmt.Sprintf("%.1f", averages[v.Sprint])
+		}
+		table.Append([]string{
+			strconv.Itoa(v.Sprint),
+			orDash(v.State),
+			strconv.Itoa(v.Committed),
+			strconv.Itoa(v.Completed),
+			fmt.Sprintf("%d%%", rate),
+			avg,
+		})
+	}
+	output.AddTable(table)
+
+	if len(completed) == 0 {
+		output.Println("終了したスプリントがないため、平均ベロシティは求められません（sprint close で終了します）。")
+	} else {
+		mean, sd := meanStdDev(completed)
+		output.Printf("平均ベロシティ: %.1f  標準偏差: %.1f（終了したスプリント %d 件）", mean, sd, len(completed))
+	}
+
+	// ==== 2. グラフ生成 ====================================================
+	labels := make([]string, len(velocities))
+	committedVals := make(plotter.Values, len(velocities))
+	completedVals := make(plotter.Values, len(velocities))
+	for i, v := range velocities {
+		labels[i] = fmt.Sprintf("Sprint %d", v.Sprint)
+		committedVals[i] = float64(v.Committed)
+		completedVals[i] = float64(v.Completed)
+	}
+
+	p := plot.New()
+	p.Title.Text = "Velocity"
+	p.Y.Label.Text = "Task Weight"
+	p.NominalX(labels...)
+
+	w := vg.Points(20)
+	committedBar, err := plotter.NewBarChart(committedVals, w)
+	if err != nil {
+		return fmt.Errorf("グラフ生成に失敗しました: %w", err)
+	}
+	committedBar.LineStyle.Width = vg.Length(0)
+	committedBar.Color = color.RGBA{54, 162, 235, 255} // 青
+	committedBar.Offset = -w / 2
+
+	completedBar, err := plotter.NewBarChart(completedVals, w)
+	if err != nil {
+		return fmt.Errorf("グラフ生成に失敗しました: %w", err)
+	}
+	completedBar.LineStyle.Width = vg.Length(0)
+	completedBar.Color = color.RGBA{75, 192, 192, 255} // 緑
+	completedBar.Offset = w / 2
+
+	p.Add(committedBar, completedBar)
+	p.Legend.Add("Committed", committedBar)
+	p.Legend.Add("Completed", completedBar)
+	p.Legend.Top = true
+
+	p.X.Padding = vg.Points(40)
+	p.X.Min = -0.5
+	p.X.Max = float64(len(labels)) - 0.5
+
+	if err := p.Save(8*vg.Inch, 4*vg.Inch, "velocity.png"); err != nil {
+		return fmt.Errorf("グラフ画像の保存に失敗しました: %w", err)
+	}
+	output.Println("ベロシティグラフ(velocity.png)を出力しました。")
+	return nil
+}
+
+// rollingAverages は終了したスプリントごとに、そのスプリントまでの直近 window 件（終了したスプリントのみ）の
+// 完了ウェイトの移動平均を求め、スプリント番号 → 移動平均で返します。
+func rollingAverages(velocities []sprintVelocity, window int) map[int]float64 {
+	averages := make(map[int]float64)
+	completed := []float64{}
+	for _, v := range velocities {
+		if !v.Closed() {
+			continue
+		}
+		completed = append(completed, float64(v.Completed))
+		start := len(completed) - window
+		if start < 0 {
+			start = 0
+		}
+		averages[v.Sprint], _ = meanStdDev(completed[start:])
+	}
+	return averages
+}
+
+// meanStdDev は平均と標準偏差（母標準偏差）を返します。
+func meanStdDev(vals []float64) (float64, float64) {
+	if len(vals) == 0 {
+		return 0, 0
+	}
+	sum := 0.0
+	for _, v := range vals {
+		sum += v
+	}
+	mean := sum / float64(len(vals))
+	sq := 0.0
+	for _, v := range vals {
+		sq += (v - mean) * (v - mean)
+	}
+	return mean, math.Sqrt(sq / float64(len(vals)))
+}
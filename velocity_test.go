@@ -0,0 +1,135 @@
+package main
+
+import (
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCollectVelocity(t *testing.T) {
+	tests := []struct {
+		name    string
+		tasks   []Task
+		sprints []Sprint
+		want    []sprintVelocity
+	}{
+		{"空", nil, nil, []sprintVelocity{}},
+		{
+			name: "スプリントごと・番号順",
+			tasks: []Task{
+				{ID: 1, Status: "done", TaskWeight: 3, SprintNumber: 2},
+				{ID: 2, Status: "doing", TaskWeight: 5, SprintNumber: 2},
+				{ID: 3, Status: "done", TaskWeight: 2, SprintNumber: 1},
+				{ID: 4, Status: "todo", TaskWeight: 8}, // バックログは含めない
+			},
+			sprints: []Sprint{{Number: 1, State: SprintClosed}, {Number: 2, State: SprintActive}},
+			want: []sprintVelocity{
+				{Sprint: 1, State: SprintClosed, Committed: 2, Completed: 2},
+				{Sprint: 2, State: SprintActive, Committed: 8, Completed: 3},
+			},
+		},
+		{
+			name:  "スプリントの記録がないタスク",
+			tasks: []Task{{ID: 1, Status: "done", TaskWeight: 3, SprintNumber: 1}},
+			want:  []sprintVelocity{{Sprint: 1, Committed: 3, Completed: 3}},
+		},
+		{
+			name:    "タスクの残っていない終了したスプリント",
+			sprints: []Sprint{{Number: 1, State: SprintClosed, CarryOver: CarryOverStats{Carried: 2, CarriedWeight: 5}}, {Number: 2, State: SprintPlanned}},
+			want:    []sprintVelocity{{Sprint: 1, State: SprintClosed, Committed: 5}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := collectVelocity(tt.tasks, tt.sprints); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("collectVelocity = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMeanStdDev(t *testing.T) {
+	tests := []struct {
+		vals     []float64
+		mean, sd float64
+	}{
+		{nil, 0, 0},
+		{[]float64{4}, 4, 0},
+		{[]float64{2, 4, 4, 4, 5, 5, 7, 9}, 5, 2},
+		{[]float64{1, 3}, 2, 1},
+	}
+	for _, tt := range tests {
+		mean, sd := meanStdDev(tt.vals)
+		if math.Abs(mean-tt.mean) > 1e-9 || math.Abs(sd-tt.sd) > 1e-9 {
+			t.Errorf("meanStdDev(%v) = %v, %v, want %v, %v", tt.vals, mean, sd, tt.mean, tt.sd)
+		}
+	}
+}
+
+func TestRollingAverages(t *testing.T) {
+	closed := func(sprint, completed int) sprintVelocity {
+		return sprintVelocity{Sprint: sprint, State: SprintClosed, Completed: completed}
+	}
+	tests := []struct {
+		name       string
+		velocities []sprintVelocity
+		window     int
+		want       map[int]float64
+	}{
+		{"空", nil, 3, map[int]float64{}},
+		{"履歴より大きい window", []sprintVelocity{closed(1, 4), closed(2, 8)}, 5, map[int]float64{1: 4, 2: 6}},
+		{"直近 window 件", []sprintVelocity{closed(1, 3), closed(2, 6), closed(3, 9), closed(4, 12)}, 2,
+			map[int]float64{1: 3, 2: 4.5, 3: 7.5, 4: 10.5}},
+		{"実行中・計画中のスプリントは含めない", []sprintVelocity{
+			closed(1, 6),
+			{Sprint: 2, State: SprintActive, Completed: 100},
+			{Sprint: 3, Completed: 100}, // スプリントの記録がない
+			closed(4, 2),
+		}, 3, map[int]float64{1: 6, 4: 4}},
+		{"タスクの残っていない終了したスプリント", []sprintVelocity{closed(1, 6), closed(2, 0)}, 3, map[int]float64{1: 6, 2: 3}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := rollingAverages(tt.velocities, tt.window); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("rollingAverages = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestShowVelocity(t *testing.T) {
+	setupSprint(t, Task{ID: 1, Title: "a", Status: "done", TaskWeight: 3, SprintNumber: 1})
+	if res := run(t, "velocity", "--window", "0"); exitCode(res.Err) != exitUsage {
+		t.Errorf("--window 0 の終了コード = %d, want %d", exitCode(res.Err), exitUsage)
+	}
+	res := mustRun(t, "velocity")
+	if got := res.Entries[0].Table.Rows[0]; !reflect.DeepEqual(got, []string{"1", SprintActive, "3", "3", "100%", "-"}) {
+		t.Errorf("スプリント 1 の行 = %q", got)
+	}
+}
+
+func TestCollectVelocityAfterClose(t *testing.T) {
+	// どの扱いでも、終了時に未完了だったタスクのウェイトは終了したスプリントの計画ウェイトに含める
+	for _, mode := range []string{carryNext, carryBacklog, carryDrop} {
+		t.Run(mode, func(t *testing.T) {
+			setupSprint(t,
+				Task{ID: 1, Title: "done", Status: "done", SprintNumber: 1, TaskWeight: 3},
+				Task{ID: 2, Title: "open", Status: "doing", SprintNumber: 1, TaskWeight: 5},
+			)
+			mustRun(t, "sprint", "close", "1", "--unfinished", mode)
+
+			sprints, err := store.ListSprints()
+			if err != nil {
+				t.Fatal(err)
+			}
+			velocities := collectVelocity(loadTasks(t), sprints)
+			if v := velocities[0]; v.Sprint != 1 || v.Committed != 8 || v.Completed != 3 {
+				t.Errorf("スプリント 1 のベロシティ = %+v, want committed 8, completed 3", v)
+			}
+			if res := mustRun(t, "sprint", "show", "1"); !strings.Contains(res.String(), "完了ウェイト/計画ウェイト: 3/8") {
+				t.Errorf("sprint show に計画ウェイト 8 が表示されません:\n%s", res)
+			}
+		})
+	}
+}
@@ -0,0 +1,184 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Workflow はタスクのステータスと許可される遷移を定義します。
+// todo_config.json の "workflow" で変更できます。
+type Workflow struct {
+	Statuses    []string            `json:"statuses"`    // 表示順
+	Transitions map[string][]string `json:"transitions"` // 遷移元 → 遷移先の一覧
+	Initial     string              `json:"initial"`     // 追加直後のステータス
+	Start       string              `json:"start"`       // 作業中（初めて割り当てたときに移動）
+	Done        string              `json:"done"`        // 完了
+}
+
+func defaultWorkflow() *Workflow {
+	return &Workflow{
+		Statuses: []string{"todo", "doing", "review", "done", "blocked"},
+		Transitions: map[string][]string{
+			"todo":    {"doing", "done", "blocked"},
+			"doing":   {"todo", "review", "done", "blocked"},
+			"review":  {"doing", "done", "blocked"},
+			"blocked": {"todo", "doing"},
+			"done":    {"doing"}, // 差し戻し
+		},
+		Initial: "todo",
+		Start:   "doing",
+		Done:    "done",
+	}
+}
+
+// workflow は現在有効なワークフローです。main で設定ファイルの内容に置き換えられます。
+var workflow = defaultWorkflow()
+
+// validate は設定ファイルから読み込んだワークフローの整合性を確認します。
+func (w *Workflow) validate() error {
+	if len(w.Statuses) == 0 {
+		return fmt.Errorf("workflow.statuses が空です")
+	}
+	for _, s := range []string{w.Initial, w.Start, w.Done} {
+		if !w.HasStatus(s) {
+			return fmt.Errorf("workflow に存在しないステータスが指定されています: %q", s)
+		}
+	}
+	for from, tos := range w.Transitions {
+		if !w.HasStatus(from) {
+			return fmt.Errorf("workflow.transitions に存在しないステータスがあります: %q", from)
+		}
+		for _, to := range tos {
+			if !w.HasStatus(to) {
+				return fmt.Errorf("workflow.transitions に存在しないステータスがあります: %q", to)
+			}
+		}
+	}
+	return nil
+}
+
+func (w *Workflow) HasStatus(status string) bool {
+	for _, s := range w.Statuses {
+		if s == status {
+			return true
+		}
+	}
+	return false
+}
+
+func (w *Workflow) CanMove(from, to string) bool {
+	for _, s := range w.Transitions[from] {
+		if s == to {
+			return true
+		}
+	}
+	return false
+}
+
+// checkMove は from → to の遷移が許可されているか確認し、不可ならエラーを返します。
+func (w *Workflow) checkMove(from, to string) error {
+	if !w.HasStatus(to) {
+		return fmt.Errorf("不明なステータスです: %s（%s）", to, strings.Join(w.Statuses, ", "))
+	}
+	if from == to {
+		return fmt.Errorf("既に %s です", to)
+	}
+	if !w.CanMove(from, to) {
+		return fmt.Errorf("%s → %s には移動できません（%s から移動できるのは: %s）",
+			from, to, from, strings.Join(w.Transitions[from], ", "))
+	}
+	return nil
+}
+
+// IsDone はタスクが完了ステータスかどうかを返します。
+func (t Task) IsDone() bool {
+	return t.Status == workflow.Done
+}
+
+// setStatus はステータスを変更し、開始日時・完了日時を記録します。
+// 完了から差し戻した場合は完了日時を消します。
+func (t *Task) setStatus(status string) {
+	now := time.Now()
+	t.Status = status
+	if status == workflow.Start && t.StartedAt == nil {
+		t.StartedAt = &now
+	}
+	if status == workflow.Done {
+		t.CompletedAt = &now
+	} else {
+		t.CompletedAt = nil
+	}
+}
+
+func timePtr(t time.Time) *time.Time {
+	return &t
+}
+
+// UnmarshalJSON は status を持たない旧形式のデータを読み込めるようにします。
+// 旧形式では done と担当者の有無から todo / doing / done を判定していました。
+func (t *Task) UnmarshalJSON(data []byte) error {
+	type taskAlias Task
+	aux := struct {
+		*taskAlias
+		Done *bool `json:"done"`
+	}{taskAlias: (*taskAlias)(t)}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+	if t.Status == "" {
+		switch {
+		case aux.Done != nil && *aux.Done:
+			t.Status = workflow.Done
+		case len(t.Assignees) > 0:
+			t.Status = workflow.Start
+		default:
+			t.Status = workflow.Initial
+		}
+	}
+	return nil
+}
+
+// MoveTask はタスクのステータスを変更します。
+func MoveTask(id int, status string) error {
+	unlock, err := store.Lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	task, err := findTask(id)
+	if err != nil {
+		return err
+	}
+
+	if err := workflow.checkMove(task.Status, status); err != nil {
+		return err
+	}
+	if status == workflow.Done {
+		warnUncheckedCriteria(task)
+	}
+	before := task
+	oldStatus := task.Status
+	task.setStatus(status)
+	if err := store.Update(task); err != nil {
+		return err
+	}
+	recordEvent(task, "move", "status", oldStatus, status)
+	recordTaskOp(fmt.Sprintf("move #%d %s", id, status), id, &before, &task)
+	// 移動は保存済みのため、以降の依存関係の表示に失敗しても警告にとどめる
+	if status == workflow.Start {
+		if tasks, err := store.List(); err != nil {
+			output.Warnf("依存先を確認できませんでした: %v", err)
+		} else if ids := blockers(task, tasksByID(tasks)); len(ids) > 0 {
+			output.Warnf("#%d は %s の完了を待っています", id, formatIDs(ids))
+		}
+	}
+	if task.IsDone() {
+		if err := reportUnblocked(id); err != nil {
+			output.Warnf("着手可能になったタスクを確認できませんでした: %v", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"testing"
+)
+
+func TestLegacyDoneToStatus(t *testing.T) {
+	tests := []struct {
+		name string
+		json string
+		want string
+	}{
+		{"done が true", `{"id":1,"title":"a","done":true,"assignees":"hanako"}`, "done"},
+		{"未完了で担当者あり", `{"id":1,"title":"a","done":false,"assignees":"hanako"}`, "doing"},
+		{"未完了で担当者なし", `{"id":1,"title":"a","done":false,"assignees":""}`, "todo"},
+		{"done がない", `{"id":1,"title":"a"}`, "todo"},
+		{"status があれば done より優先", `{"id":1,"title":"a","done":true,"status":"review"}`, "review"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var task Task
+			if err := json.Unmarshal([]byte(tt.json), &task); err != nil {
+				t.Fatal(err)
+			}
+			if task.Status != tt.want {
+				t.Errorf("status = %q, want %q", task.Status, tt.want)
+			}
+		})
+	}
+}
+
+func TestLegacyDoneRoundTrip(t *testing.T) {
+	// 旧形式のファイルを migrate で書き戻すと done がなくなり status が保存される
+	setupStore(t)
+	legacy := `[{"id":1,"title":"a","done":true,"assignees":"hanako"},{"id":2,"title":"b","done":false,"assignees":"taro"}]`
+	if err := os.WriteFile(store.Path(), []byte(legacy), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	mustRun(t, "migrate")
+
+	data, err := os.ReadFile(store.Path())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var saved []map[string]interface{}
+	if err := json.Unmarshal(data, &saved); err != nil {
+		t.Fatal(err)
+	}
+	for i, want := range []string{"done", "doing"} {
+		if _, ok := saved[i]["done"]; ok {
+			t.Errorf("tasks[%d] に done が残っています", i)
+		}
+		if got := saved[i]["status"]; got != want {
+			t.Errorf("tasks[%d].status = %v, want %s", i, got, want)
+		}
+	}
+}
+
+func TestWorkflowTransitions(t *testing.T) {
+	tests := []struct {
+		from, to string
+		ok       bool
+	}{
+		{"todo", "doing", true},
+		{"todo", "done", true},
+		{"doing", "review", true},
+		{"review", "done", true},
+		{"done", "doing", true}, // 差し戻し
+		{"blocked", "doing", true},
+		{"todo", "review", false},
+		{"done", "todo", false},
+		{"blocked", "done", false},
+		{"todo", "todo", false},
+		{"todo", "wip", false}, // 不明なステータス
+	}
+	for _, tt := range tests {
+		err := defaultWorkflow().checkMove(tt.from, tt.to)
+		if (err == nil) != tt.ok {
+			t.Errorf("checkMove(%s, %s) の err = %v, want ok=%v", tt.from, tt.to, err, tt.ok)
+		}
+	}
+}
+
+func TestMoveCommand(t *testing.T) {
+	setupStore(t, Task{ID: 1, Title: "a", Status: "todo", TaskWeight: 3})
+
+	if res := run(t, "move", "1", "review"); res.Err == nil {
+		t.Error("todo → review の移動がエラーになりません")
+	}
+	mustRun(t, "move", "1", "doing")
+	task := getTask(t, 1)
+	if task.Status != "doing" || task.StartedAt == nil {
+		t.Errorf("#1 = %s, started_at = %v, want doing と着手日時", task.Status, task.StartedAt)
+	}
+	mustRun(t, "move", "1", "done")
+	if task := getTask(t, 1); task.CompletedAt == nil {
+		t.Error("完了日時が記録されていません")
+	}
+	mustRun(t, "move", "1", "doing")
+	if task := getTask(t, 1); task.CompletedAt != nil {
+		t.Error("差し戻したタスクに完了日時が残っています")
+	}
+}